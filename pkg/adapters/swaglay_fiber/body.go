@@ -0,0 +1,100 @@
+package swaglay_fiber
+
+import (
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_qf"
+	"github.com/gofiber/fiber/v3"
+	"net/http"
+	"reflect"
+)
+
+// satisfyBody binds the input from the JSON body and the parameters, then validates it.
+// If the input is invalid, the error response is sent and nil is returned.
+func satisfyBody[In any](r *Registrar, ctx fiber.Ctx) *In {
+	var input In
+	setCtxIfNeeded(&input, ctx)
+
+	// The input is validated once the parameters are bound too.
+	err := ctx.Bind().SkipValidation(true).JSON(&input)
+	ctx.Bind().SkipValidation(false)
+	if err != nil {
+		r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
+
+		return nil
+	}
+
+	if err = bindParameters(&input, ctx); err != nil {
+		r.sendInputError(ctx, http.StatusBadRequest, err)
+
+		return nil
+	}
+
+	if err = validateInput(ctx, &input); err != nil {
+		r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
+
+		return nil
+	}
+
+	return &input
+}
+
+// satisfyComposite binds the field tagged body of a composite input from the JSON or form body, if there is one,
+// and the other fields from the parameters, then validates it.
+// If the input is invalid, the error response is sent and nil is returned.
+func satisfyComposite[In any](r *Registrar, ctx fiber.Ctx) *In {
+	var input In
+	setCtxIfNeeded(&input, ctx)
+
+	field, _ := swaglay_qf.GetBodyField(reflect.TypeFor[In]())
+	body := reflect.ValueOf(&input).Elem().FieldByIndex(field.Index)
+	if bodyType := body.Type(); rest.IsForm(bodyType) || (bodyType.Kind() == reflect.Pointer && rest.IsForm(bodyType.Elem())) {
+		if body.Kind() == reflect.Pointer {
+			body.Set(reflect.New(bodyType.Elem()))
+			body = body.Elem()
+		}
+		if err := bindForm(body.Addr().Interface(), ctx); err != nil {
+			r.sendInputError(ctx, formErrorStatus(err), err)
+
+			return nil
+		}
+	} else if len(ctx.Body()) > 0 {
+		if err := ctx.App().Config().JSONDecoder(ctx.Body(), body.Addr().Interface()); err != nil {
+			r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
+
+			return nil
+		}
+	}
+
+	if err := bindParameters(&input, ctx); err != nil {
+		r.sendInputError(ctx, http.StatusBadRequest, err)
+
+		return nil
+	}
+
+	if err := validateInput(ctx, &input); err != nil {
+		r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
+
+		return nil
+	}
+
+	return &input
+}
+
+// validateInput validates the input with the struct validator of the app, as Fiber validates bound structs.
+func validateInput(ctx fiber.Ctx, input any) error {
+	validator := ctx.App().Config().StructValidator
+	if validator == nil || reflect.TypeOf(input).Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	if err := validator.Validate(input); err != nil {
+		return &inputValidationError{input: reflect.TypeOf(input), err: err}
+	}
+
+	return nil
+}
+
+// sendInputError responds to a request whose input can't be bound, with the error body of the registrar.
+func (r *Registrar) sendInputError(ctx fiber.Ctx, status int, err error) {
+	r.sendErrorBody(ctx, status, r.responseErrorBody(ctx, err))
+}
@@ -2,26 +2,137 @@ package swaglay_fiber
 
 import (
 	"errors"
+	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 	"net/http"
 )
 
+// Fiber is the router that the package level functions, such as GetIO, register routes on.
+//
+// Deprecated: create a Registrar with NewRegistrar, which doesn't share state between controllers.
 var Fiber fiber.Router
+
+// FiberApp is the app of Fiber, if Fiber is a group, used to tell whether Fiber documents HEAD routes for GET routes.
+// The struct validator is taken from the app that serves the request.
+//
+// Deprecated: create a Registrar with NewRegistrar.
 var FiberApp *fiber.App
 
+// NewResponseErrorBody creates the body of an error response of the package level functions,
+// problem details if swaglay.Api is in the problem details mode, see rest.WithProblemDetails.
 var NewResponseErrorBody = func(ctx fiber.Ctx, err error) any {
-	return map[string]string{"error": err.Error()}
+	return defaultResponseErrorBody(swaglay.Api, Translator, ctx, err)
 }
 
+// Translator translates the messages of the validation errors of the package level functions, see WithTranslator.
+var Translator *ut.UniversalTranslator
+
+// NewResponseError maps an error of the package level functions to the status and body of the response.
 var NewResponseError = func(ctx fiber.Ctx, err error) (int, any) {
-	status := http.StatusInternalServerError
+	return defaultResponseError(swaglay.Api, ctx, err, NewResponseErrorBody)
+}
+
+// OnHandleError is called with the errors of the package level functions.
+var OnHandleError = func(ctx fiber.Ctx, err error) {}
+
+// DefaultResponseErrorBody creates an error response body with the message of the error.
+func DefaultResponseErrorBody(_ fiber.Ctx, err error) any {
+	return map[string]string{"error": err.Error()}
+}
+
+// ProblemDetailsBody creates the problem details of an error response, see RFC 9457.
+// Their status and title are set when the response is sent.
+func ProblemDetailsBody(ctx fiber.Ctx, err error) any {
+	problem := rest.NewProblemDetails(0, err.Error())
+	problem.Instance = ctx.Path()
+	return problem
+}
+
+// defaultResponseErrorBody creates problem details in the problem details mode of the API, and DefaultResponseErrorBody otherwise.
+// The fields of validation errors are listed, see dtos.UnprocessableEntity, as extension members of the problem details.
+func defaultResponseErrorBody(api *rest.API, translator *ut.UniversalTranslator, ctx fiber.Ctx, err error) any {
+	validationBody, isValidationError := newValidationErrorBody(ctx, err, translator)
+
+	if api != nil && api.UseProblemDetails {
+		problem := ProblemDetailsBody(ctx, err).(*rest.ProblemDetails)
+		if isValidationError {
+			problem.WithExtensions(validationBody)
+		}
+		return problem
+	}
+
+	if isValidationError {
+		return validationBody
+	}
+
+	return DefaultResponseErrorBody(ctx, err)
+}
+
+// defaultResponseError maps the error to the response of the registered error it matches, see rest.API.RegisterError,
+// otherwise to the default status. The body is created by newBody, unless the registered error has one.
+func defaultResponseError(api *rest.API, ctx fiber.Ctx, err error, newBody func(ctx fiber.Ctx, err error) any) (int, any) {
+	if api != nil {
+		if response, body, ok := api.MapError(err); ok {
+			// In the problem details mode, the body of the registered error is added to the extension members.
+			if api.UseProblemDetails {
+				return response.Status, newRegisteredProblemDetails(ctx, err, response, body)
+			}
+			if body == nil {
+				body = newBody(ctx, err)
+			}
+			return response.Status, body
+		}
+	}
+
+	return defaultResponseErrorStatus(err), newBody(ctx, err)
+}
+
+// defaultResponseErrorStatus is 422 for validation errors, and 500 otherwise.
+func defaultResponseErrorStatus(err error) int {
 	var validationErrors validator.ValidationErrors
 	if errors.As(err, &validationErrors) {
-		status = http.StatusUnprocessableEntity
+		return http.StatusUnprocessableEntity
 	}
 
-	return status, NewResponseErrorBody(ctx, err)
+	return http.StatusInternalServerError
 }
 
-var OnHandleError = func(ctx fiber.Ctx, err error) {}
+// newRegisteredProblemDetails creates the problem details of a registered error, titled by its description.
+func newRegisteredProblemDetails(ctx fiber.Ctx, err error, response rest.ErrorResponse, body any) *rest.ProblemDetails {
+	problem := rest.NewProblemDetails(response.Status, err.Error())
+	if response.Type != "" {
+		problem.Type = response.Type
+	}
+	if response.Description != "" {
+		problem.Title = response.Description
+	}
+	problem.Instance = ctx.Path()
+	if body != nil {
+		problem.WithExtensions(body)
+	}
+
+	return problem
+}
+
+// sendErrorBody sends the body of an error response with the status,
+// problem details are sent as application/problem+json with the status and its text as title, unless they're set.
+func (r *Registrar) sendErrorBody(ctx fiber.Ctx, status int, body any) {
+	var err error
+	if problem, ok := body.(*rest.ProblemDetails); ok {
+		if problem.Status == 0 {
+			problem.Status = status
+		}
+		if problem.Title == "" {
+			problem.Title = http.StatusText(status)
+		}
+		err = ctx.Status(status).JSON(problem, rest.ProblemJSONContentType)
+	} else {
+		err = ctx.Status(status).JSON(body)
+	}
+	if err != nil {
+		r.onHandleError(ctx, err)
+	}
+}
@@ -0,0 +1,198 @@
+package swaglay_fiber
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_qf"
+	"github.com/gofiber/fiber/v3"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+// satisfyForm binds the input from the form body and the parameters, then validates it.
+// If the input is invalid, the error response is sent and nil is returned.
+func satisfyForm[In any](r *Registrar, ctx fiber.Ctx) *In {
+	var input In
+	setCtxIfNeeded(&input, ctx)
+
+	if err := bindForm(&input, ctx); err != nil {
+		r.sendInputError(ctx, formErrorStatus(err), err)
+
+		return nil
+	}
+
+	if err := bindParameters(&input, ctx); err != nil {
+		r.sendInputError(ctx, http.StatusBadRequest, err)
+
+		return nil
+	}
+
+	if err := validateInput(ctx, &input); err != nil {
+		r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
+
+		return nil
+	}
+
+	return &input
+}
+
+// formErrorStatus is the status of the response to a form that can't be bound.
+func formErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, rest.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, rest.ErrUnsupportedFileType), errors.Is(err, errUnsupportedContentType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// bindForm binds the fields tagged form of the input, a pointer to a struct, from a multipart/form-data
+// or an application/x-www-form-urlencoded body. The limits of the files are checked.
+func bindForm(input any, ctx fiber.Ctx) error {
+	values := make(map[string][]string)
+	var files map[string][]*multipart.FileHeader
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	switch mediaType {
+	case rest.MultipartFormContentType:
+		// Forms larger than their files can be are rejected before they're parsed.
+		maxSize, err := getFormMaxSize(reflect.TypeOf(input).Elem())
+		if err != nil {
+			return err
+		}
+		if size := int64(ctx.Request().Header.ContentLength()); maxSize > 0 && size > maxSize {
+			return fmt.Errorf("%w: the form is %d bytes, the maximum is %d bytes", rest.ErrFileTooLarge, size, maxSize)
+		}
+
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return err
+		}
+		values, files = form.Value, form.File
+	case rest.URLEncodedFormContentType:
+		for key, value := range ctx.Request().PostArgs().All() {
+			values[string(key)] = append(values[string(key)], string(value))
+		}
+	default:
+		return fmt.Errorf("%w %q, expected %s or %s", errUnsupportedContentType, mediaType,
+			rest.MultipartFormContentType, rest.URLEncodedFormContentType)
+	}
+
+	v := reflect.ValueOf(input).Elem()
+	for _, field := range swaglay_qf.GetParameterFields(v.Type(), rest.FormTag) {
+		name, _, _ := strings.Cut(field.Name, ",")
+		fieldValue := v.FieldByIndex(field.Index)
+
+		var err error
+		if rest.IsFileType(field.Field.Type) {
+			err = setFormFiles(fieldValue, field.Field.Tag, files[name])
+		} else {
+			err = setFormValues(fieldValue, values[name])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid form field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// maxFormOverhead is the size allowed for the fields other than the files of a multipart form,
+// and for the headers of its parts.
+const maxFormOverhead = 64 << 10
+
+// getFormMaxSize returns the maximum size of a multipart form of the type, the sum of the maximum sizes of its files
+// and maxFormOverhead. It's 0, without limit, if the form has no files, if a file has no maximum size,
+// or if a field is a slice of files.
+func getFormMaxSize(t reflect.Type) (int64, error) {
+	var maxSize int64
+	for _, field := range swaglay_qf.GetParameterFields(t, rest.FormTag) {
+		if !rest.IsFileType(field.Field.Type) {
+			continue
+		}
+		if field.Field.Type.Kind() == reflect.Slice {
+			return 0, nil
+		}
+		limits, err := rest.GetFileLimits(field.Field.Tag)
+		if err != nil {
+			return 0, err
+		}
+		if limits.MaxSize == 0 {
+			return 0, nil
+		}
+		maxSize += limits.MaxSize
+	}
+	if maxSize == 0 {
+		return 0, nil
+	}
+	return maxSize + maxFormOverhead, nil
+}
+
+// setFormValues sets the field, or the elements of a slice field, to the values of the form field.
+func setFormValues(field reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if field.Kind() != reflect.Slice {
+		return swaglay_qf.SetParameterValue(field, values[0])
+	}
+
+	elements := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := swaglay_qf.SetParameterValue(elements.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(elements)
+
+	return nil
+}
+
+// setFormFiles sets a File field, a pointer to a File, or a slice of them, to the files of the form field,
+// once their limits are checked.
+func setFormFiles(field reflect.Value, tag reflect.StructTag, headers []*multipart.FileHeader) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	limits, err := rest.GetFileLimits(tag)
+	if err != nil {
+		return err
+	}
+
+	files := make([]rest.File, len(headers))
+	for i, header := range headers {
+		files[i] = rest.NewFile(header)
+		if err = limits.Check(files[i]); err != nil {
+			return err
+		}
+	}
+
+	if field.Kind() == reflect.Slice {
+		elements := reflect.MakeSlice(field.Type(), len(files), len(files))
+		for i, file := range files {
+			setFile(elements.Index(i), file)
+		}
+		field.Set(elements)
+	} else {
+		setFile(field, files[0])
+	}
+
+	return nil
+}
+
+func setFile(field reflect.Value, file rest.File) {
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	field.Set(reflect.ValueOf(file))
+}
@@ -1,7 +1,12 @@
 package swaglay_fiber
 
 import (
+	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_qf"
 	"github.com/gofiber/fiber/v3"
+	"net/http"
+	"reflect"
 )
 
 type HandleFnIO[In any, Out any] func(i *In, ctx fiber.Ctx) (Out, error)
@@ -9,74 +14,271 @@ type HandleFnI[In any] func(i *In, ctx fiber.Ctx) error
 type HandleFnO[Out any] func(ctx fiber.Ctx) (Out, error)
 type HandleFn func(ctx fiber.Ctx) error
 
-func handleIO[In any, Out any](i *In, ctx fiber.Ctx, fn HandleFnIO[In, Out]) {
-	output, err := fn(i, ctx)
-	if err != nil {
-		OnHandleError(ctx, err)
+// Handler is a handler function together with its input and output types,
+// created with Handle, HandleI, HandleO or HandleIO, that a Registrar registers and documents.
+type Handler interface {
+	hasInput() bool
+	hasOutput() bool
+	// document registers the route of the handler in the API.
+	document(api *rest.API, apiResource, url, method, name string, opts []Opts)
+	// newAction creates the Fiber handler, that binds the input from the body, the query string, or both for a composite input,
+	// and responds with the status, unless the output is a swaglay.Result with another status.
+	// The options are returned with the middleware that binds the input, if UseWithInput is set.
+	newAction(r *Registrar, bodyInput bool, status int, opts []Opts) (fiber.Handler, []Opts)
+}
 
-		status, data := NewResponseError(ctx, err)
+// Handle creates a Handler without input and output.
+func Handle(fn HandleFn) Handler {
+	return handler{fn: fn}
+}
 
-		err = ctx.Status(status).JSON(data)
-		if err != nil {
-			OnHandleError(ctx, err)
-		}
+// HandleI creates a Handler with input.
+func HandleI[In any](fn HandleFnI[In]) Handler {
+	return handlerI[In]{fn: fn}
+}
 
-		return
+// HandleO creates a Handler with output.
+func HandleO[Out any](fn HandleFnO[Out]) Handler {
+	return handlerO[Out]{fn: fn}
+}
+
+// HandleIO creates a Handler with input and output.
+func HandleIO[In any, Out any](fn HandleFnIO[In, Out]) Handler {
+	return handlerIO[In, Out]{fn: fn}
+}
+
+type handler struct {
+	fn HandleFn
+}
+
+func (h handler) hasInput() bool {
+	return false
+}
+
+func (h handler) hasOutput() bool {
+	return false
+}
+
+func (h handler) document(api *rest.API, apiResource, url, method, name string, opts []Opts) {
+	if len(opts) > 0 && opts[0].Out != nil {
+		swaglay.RegisterHandlerVarOFor(api, apiResource, url, method, name, opts[0].Out)
+	} else {
+		swaglay.RegisterHandlerFor(api, apiResource, url, method, name)
 	}
+}
+
+func (h handler) newAction(r *Registrar, _ bool, status int, opts []Opts) (fiber.Handler, []Opts) {
+	return func(ctx fiber.Ctx) error {
+		r.handle(ctx, status, h.fn)
+
+		return nil
+	}, opts
+}
+
+type handlerI[In any] struct {
+	fn HandleFnI[In]
+}
+
+func (h handlerI[In]) hasInput() bool {
+	return true
+}
 
-	if err = ctx.JSON(output); err != nil {
-		OnHandleError(ctx, err)
+func (h handlerI[In]) hasOutput() bool {
+	return false
+}
+
+func (h handlerI[In]) document(api *rest.API, apiResource, url, method, name string, opts []Opts) {
+	if len(opts) > 0 && opts[0].Out != nil {
+		swaglay.RegisterHandlerIVarOFor[In](api, apiResource, url, method, name, opts[0].Out)
+	} else {
+		swaglay.RegisterHandlerIFor[In](api, apiResource, url, method, name)
 	}
 }
 
-func handleI[In any](i *In, ctx fiber.Ctx, fn HandleFnI[In]) {
-	err := fn(i, ctx)
-	if err != nil {
-		OnHandleError(ctx, err)
+func (h handlerI[In]) newAction(r *Registrar, bodyInput bool, status int, opts []Opts) (fiber.Handler, []Opts) {
+	return newInputAction(r, bodyInput, opts, func(input *In, ctx fiber.Ctx) {
+		handleI(r, input, ctx, status, h.fn)
+	})
+}
 
-		status, data := NewResponseError(ctx, err)
+type handlerO[Out any] struct {
+	fn HandleFnO[Out]
+}
 
-		err = ctx.Status(status).JSON(data)
-		if err != nil {
-			OnHandleError(ctx, err)
-		}
+func (h handlerO[Out]) hasInput() bool {
+	return false
+}
 
-		return
+func (h handlerO[Out]) hasOutput() bool {
+	return true
+}
+
+func (h handlerO[Out]) document(api *rest.API, apiResource, url, method, name string, opts []Opts) {
+	if len(opts) > 0 && opts[0].Out != nil {
+		swaglay.RegisterHandlerVarOFor(api, apiResource, url, method, name, opts[0].Out)
+	} else {
+		swaglay.RegisterHandlerOFor[Out](api, apiResource, url, method, name)
 	}
 }
 
-func handleO[Out any](ctx fiber.Ctx, fn HandleFnO[Out]) {
-	output, err := fn(ctx)
-	if err != nil {
-		OnHandleError(ctx, err)
+func (h handlerO[Out]) newAction(r *Registrar, _ bool, status int, opts []Opts) (fiber.Handler, []Opts) {
+	return func(ctx fiber.Ctx) error {
+		handleO(r, ctx, status, h.fn)
 
-		status, data := NewResponseError(ctx, err)
+		return nil
+	}, opts
+}
 
-		err = ctx.Status(status).JSON(data)
-		if err != nil {
-			OnHandleError(ctx, err)
+type handlerIO[In any, Out any] struct {
+	fn HandleFnIO[In, Out]
+}
+
+func (h handlerIO[In, Out]) hasInput() bool {
+	return true
+}
+
+func (h handlerIO[In, Out]) hasOutput() bool {
+	return true
+}
+
+func (h handlerIO[In, Out]) document(api *rest.API, apiResource, url, method, name string, opts []Opts) {
+	if len(opts) > 0 && opts[0].Out != nil {
+		swaglay.RegisterHandlerIVarOFor[In](api, apiResource, url, method, name, opts[0].Out)
+	} else {
+		swaglay.RegisterHandlerIOFor[In, Out](api, apiResource, url, method, name)
+	}
+}
+
+func (h handlerIO[In, Out]) newAction(r *Registrar, bodyInput bool, status int, opts []Opts) (fiber.Handler, []Opts) {
+	return newInputAction(r, bodyInput, opts, func(input *In, ctx fiber.Ctx) {
+		handleIO(r, input, ctx, status, h.fn)
+	})
+}
+
+// newInputAction creates the Fiber handler that binds the input and calls fn with it.
+// The input is bound from the JSON or form body, or the query string, depending on the method,
+// unless it's a composite input.
+// With UseWithInput, the input is bound by a middleware instead, so that the other middlewares can use it.
+func newInputAction[In any](r *Registrar, bodyInput bool, opts []Opts, fn func(input *In, ctx fiber.Ctx)) (fiber.Handler, []Opts) {
+	t := reflect.TypeFor[In]()
+	satisfy := satisfyQuery[In]
+	if _, composite := swaglay_qf.GetBodyField(t); composite {
+		satisfy = satisfyComposite[In]
+	} else if bodyInput && rest.IsForm(t) {
+		satisfy = satisfyForm[In]
+	} else if bodyInput {
+		satisfy = satisfyBody[In]
+	}
+
+	if len(opts) > 0 && opts[0].UseWithInput {
+		opts = wrapInputMiddleware(r, satisfy, opts)
+		return func(ctx fiber.Ctx) error {
+			if input := ctx.Locals("input").(*In); input != nil {
+				fn(input, ctx)
+			}
+
+			return nil
+		}, opts
+	}
+
+	return func(ctx fiber.Ctx) error {
+		if input := satisfy(r, ctx); input != nil {
+			fn(input, ctx)
 		}
 
+		return nil
+	}, opts
+}
+
+func handleIO[In any, Out any](r *Registrar, i *In, ctx fiber.Ctx, status int, fn HandleFnIO[In, Out]) {
+	output, err := fn(i, ctx)
+	if err != nil {
+		r.handleError(ctx, err)
+
 		return
 	}
 
-	if err = ctx.JSON(output); err != nil {
-		OnHandleError(ctx, err)
+	r.respond(ctx, status, output)
+}
+
+// handleI calls the handler with the status of the operation already set, the handler can still change it.
+func handleI[In any](r *Registrar, i *In, ctx fiber.Ctx, status int, fn HandleFnI[In]) {
+	ctx.Status(status)
+	err := fn(i, ctx)
+	if err != nil {
+		r.handleError(ctx, err)
 	}
 }
 
-func handle(ctx fiber.Ctx, fn HandleFn) {
-	err := fn(ctx)
+func handleO[Out any](r *Registrar, ctx fiber.Ctx, status int, fn HandleFnO[Out]) {
+	output, err := fn(ctx)
 	if err != nil {
-		OnHandleError(ctx, err)
+		r.handleError(ctx, err)
 
-		status, data := NewResponseError(ctx, err)
+		return
+	}
+
+	r.respond(ctx, status, output)
+}
+
+// handle calls the handler with the status of the operation already set, the handler can still change it.
+func (r *Registrar) handle(ctx fiber.Ctx, status int, fn HandleFn) {
+	ctx.Status(status)
+	err := fn(ctx)
+	if err != nil {
+		r.handleError(ctx, err)
+	}
+}
 
-		err = ctx.Status(status).JSON(data)
+// respond sends the output of a handler as JSON with the status of the operation,
+// or with the status and the headers of a swaglay.Result, or the status of the response set in a swaglay.Responses.
+// Responses with statuses without body are sent empty, and error responses are problem details in the problem details mode.
+func (r *Registrar) respond(ctx fiber.Ctx, status int, output any) {
+	if result, ok := output.(swaglay.Resulter); ok {
+		resultStatus, header, body := result.Result()
+		if resultStatus != 0 {
+			status = resultStatus
+		}
+		for key, values := range header {
+			for _, value := range values {
+				ctx.Append(key, value)
+			}
+		}
+		output = body
+	}
+	if swaglay.GetResponseFields(reflect.TypeOf(output)) != nil {
+		responseStatus, body, err := swaglay.GetResponse(output)
 		if err != nil {
-			OnHandleError(ctx, err)
+			r.handleError(ctx, err)
+
+			return
 		}
+		status, output = responseStatus, body
+	}
+
+	ctx.Status(status)
+	if !swaglay.HasResponseBody(status) {
+		return
+	}
+
+	// In the problem details mode, error responses are problem details extended with the output, as they're documented.
+	if status >= http.StatusBadRequest && r.api != nil && r.api.UseProblemDetails {
+		problem := rest.NewProblemDetails(status, "").WithExtensions(output)
+		problem.Instance = ctx.Path()
+		r.sendErrorBody(ctx, status, problem)
 
 		return
 	}
+
+	if err := ctx.JSON(output); err != nil {
+		r.onHandleError(ctx, err)
+	}
+}
+
+// handleError reports the error of a handler to the hook, and responds with the mapped error.
+func (r *Registrar) handleError(ctx fiber.Ctx, err error) {
+	r.onHandleError(ctx, err)
+
+	status, data := r.responseError(ctx, err)
+	r.sendErrorBody(ctx, status, data)
 }
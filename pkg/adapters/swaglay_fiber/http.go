@@ -1,9 +1,8 @@
 package swaglay_fiber
 
 import (
-	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/rest"
 	"github.com/gofiber/fiber/v3"
-	"net/http"
 )
 
 type Opts struct {
@@ -11,36 +10,29 @@ type Opts struct {
 	Use          fiber.Handler
 	Uses         []fiber.Handler
 	UseWithInput bool
-}
-
-func wrapBodyInputMiddleware[In any](opts []Opts) []Opts {
+	// Security requirements documented for the route, any of them grants access.
+	// The schemes must be registered in the API of the route, e.g. with rest.WithSecurityScheme.
+	Security []rest.SecurityRequirement
+	// Api the route is documented in, the API of the Registrar, or swaglay.Api, by default.
+	// The route responds as it's documented, with the registered errors and problem details of the API,
+	// unless the errors are mapped by WithErrorMapper or NewResponseError.
+	// Create it with swaglay.NewApi, e.g. to document admin routes in a separate specification.
+	Api *rest.API
+	// ValidateRequest validates the requests against the operation of the route in the specification document,
+	// before the middlewares and the handler, see WithRequestValidation.
+	ValidateRequest bool
+}
+
+// wrapInputMiddleware adds a middleware before the others, that binds the input with satisfy,
+// and keeps it in the "input" local for the middlewares and the handler.
+func wrapInputMiddleware[In any](r *Registrar, satisfy func(r *Registrar, ctx fiber.Ctx) *In, opts []Opts) []Opts {
 	uses := make([]fiber.Handler, len(opts[0].Uses)+1)
 	uses[0] = func(ctx fiber.Ctx) error {
-		var input In
-		setCtxIfNeeded(&input, ctx)
-		if err := ctx.Bind().JSON(&input); err != nil {
-			return ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
+		input := satisfy(r, ctx)
+		if input == nil {
+			return nil
 		}
-		ctx.Locals("input", &input)
-		return ctx.Next()
-	}
-
-	for i, use := range opts[0].Uses {
-		uses[i+1] = use
-	}
-
-	opts[0].Uses = uses
-	return opts
-}
-
-func wrapSatisfyQueryInputMiddleware[In any](opts []Opts) []Opts {
-	uses := make([]fiber.Handler, len(opts[0].Uses)+1)
-
-	uses[0] = func(ctx fiber.Ctx) error {
-		input := satisfyQuery[In](ctx)
-
 		ctx.Locals("input", input)
-
 		return ctx.Next()
 	}
 
@@ -49,7 +41,6 @@ func wrapSatisfyQueryInputMiddleware[In any](opts []Opts) []Opts {
 	}
 
 	opts[0].Uses = uses
-
 	return opts
 }
 
@@ -59,6 +50,17 @@ func assertUnsupportedUseWithInput(opts []Opts) {
 	}
 }
 
+func applyRouteOpts(api *rest.API, method, path string, opts []Opts) {
+	if len(opts) == 0 {
+		return
+	}
+
+	route := api.Route(method, path)
+	for _, requirement := range opts[0].Security {
+		route.HasSecurityRequirement(requirement)
+	}
+}
+
 func getMiddlewares(opts []Opts) []any {
 	if len(opts) == 0 {
 		return nil
@@ -83,480 +85,89 @@ func setCtxIfNeeded(input any, ctx fiber.Ctx) {
 }
 
 func Get(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodGet, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandler(apiResource, fullPath(url), http.MethodGet, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handle(ctx, fn)
-
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Get(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Get(apiResource, url, Handle(fn), name, opts...)
 }
 
 func GetI[In any](apiResource, url string, fn HandleFnI[In], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodGet, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerI[In](apiResource, fullPath(url), http.MethodGet, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapSatisfyQueryInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleI(input, ctx, fn)
-			}
-
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			if input := satisfyQuery[In](ctx); input != nil {
-				handleI(input, ctx, fn)
-			}
-
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Get(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Get(apiResource, url, HandleI(fn), name, opts...)
 }
 
 func GetO[Out any](apiResource, url string, fn HandleFnO[Out], name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodGet, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerO[Out](apiResource, fullPath(url), http.MethodGet, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handleO(ctx, fn)
-
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Get(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Get(apiResource, url, HandleO(fn), name, opts...)
 }
 
 func GetIO[In any, Out any](apiResource, url string, fn HandleFnIO[In, Out], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodGet, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerIO[In, Out](apiResource, fullPath(url), http.MethodGet, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapSatisfyQueryInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleIO(input, ctx, fn)
-			}
-
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			if input := satisfyQuery[In](ctx); input != nil {
-				handleIO(input, ctx, fn)
-			}
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Get(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Get(apiResource, url, HandleIO(fn), name, opts...)
 }
 
 func Post(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodPost, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandler(apiResource, fullPath(url), http.MethodPost, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handle(ctx, fn)
-
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Post(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Post(apiResource, url, Handle(fn), name, opts...)
 }
 
 func PostI[In any](apiResource, url string, fn HandleFnI[In], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodPost, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerI[In](apiResource, fullPath(url), http.MethodPost, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapBodyInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleI(input, ctx, fn)
-			}
-
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			var input In
-			setCtxIfNeeded(&input, ctx)
-			if err := ctx.Bind().JSON(&input); err != nil {
-				return ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
-			}
-
-			handleI(&input, ctx, fn)
-
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Post(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Post(apiResource, url, HandleI(fn), name, opts...)
 }
 
 func PostO[Out any](apiResource, url string, fn HandleFnO[Out], name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodPost, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerO[Out](apiResource, fullPath(url), http.MethodPost, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handleO(ctx, fn)
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Post(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Post(apiResource, url, HandleO(fn), name, opts...)
 }
 
 func PostIO[In any, Out any](apiResource, url string, fn HandleFnIO[In, Out], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodPost, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerIO[In, Out](apiResource, fullPath(url), http.MethodPost, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapBodyInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleIO(input, ctx, fn)
-			}
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			var input In
-			setCtxIfNeeded(&input, ctx)
-			if err := ctx.Bind().JSON(&input); err != nil {
-				return ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
-			}
-			handleIO(&input, ctx, fn)
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Post(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Post(apiResource, url, HandleIO(fn), name, opts...)
 }
 
 func Put(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodPut, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandler(apiResource, fullPath(url), http.MethodPut, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handle(ctx, fn)
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Put(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Put(apiResource, url, Handle(fn), name, opts...)
 }
 
 func PutI[In any](apiResource, url string, fn HandleFnI[In], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodPut, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerI[In](apiResource, fullPath(url), http.MethodPut, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapBodyInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleI(input, ctx, fn)
-			}
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			var input In
-			setCtxIfNeeded(&input, ctx)
-			if err := ctx.Bind().JSON(&input); err != nil {
-				return ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
-			}
-			handleI(&input, ctx, fn)
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Put(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Put(apiResource, url, HandleI(fn), name, opts...)
 }
 
 func PutO[Out any](apiResource, url string, fn HandleFnO[Out], name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodPut, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerO[Out](apiResource, fullPath(url), http.MethodPut, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handleO(ctx, fn)
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Put(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Put(apiResource, url, HandleO(fn), name, opts...)
 }
 
 func PutIO[In any, Out any](apiResource, url string, fn HandleFnIO[In, Out], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodPut, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerIO[In, Out](apiResource, fullPath(url), http.MethodPut, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapBodyInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleIO(input, ctx, fn)
-			}
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			var input In
-			setCtxIfNeeded(&input, ctx)
-			if err := ctx.Bind().JSON(&input); err != nil {
-				return ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
-			}
-			handleIO(&input, ctx, fn)
-			return nil
-		}
-	}
+	newGlobalRegistrar().Put(apiResource, url, HandleIO(fn), name, opts...)
+}
 
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
+func Patch(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
+	newGlobalRegistrar().Patch(apiResource, url, Handle(fn), name, opts...)
+}
 
-	Fiber.Put(replacePath(url), handlers[0], handlers[1:]...)
+func PatchI[In any](apiResource, url string, fn HandleFnI[In], name string, opts ...Opts) {
+	newGlobalRegistrar().Patch(apiResource, url, HandleI(fn), name, opts...)
 }
 
-func Delete(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
+func PatchO[Out any](apiResource, url string, fn HandleFnO[Out], name string, opts ...Opts) {
+	newGlobalRegistrar().Patch(apiResource, url, HandleO(fn), name, opts...)
+}
 
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodDelete, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandler(apiResource, fullPath(url), http.MethodDelete, name)
-	}
+func PatchIO[In any, Out any](apiResource, url string, fn HandleFnIO[In, Out], name string, opts ...Opts) {
+	newGlobalRegistrar().Patch(apiResource, url, HandleIO(fn), name, opts...)
+}
 
-	action := func(ctx fiber.Ctx) error {
-		handle(ctx, fn)
-		return nil
-	}
+func Head(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
+	newGlobalRegistrar().Head(apiResource, url, Handle(fn), name, opts...)
+}
 
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
+func Options(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
+	newGlobalRegistrar().Options(apiResource, url, Handle(fn), name, opts...)
+}
 
-	Fiber.Delete(replacePath(url), handlers[0], handlers[1:]...)
+func Delete(apiResource, url string, fn HandleFn, name string, opts ...Opts) {
+	newGlobalRegistrar().Delete(apiResource, url, Handle(fn), name, opts...)
 }
 
 func DeleteI[In any](apiResource, url string, fn HandleFnI[In], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodDelete, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerI[In](apiResource, fullPath(url), http.MethodDelete, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapSatisfyQueryInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleI(input, ctx, fn)
-			}
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			if input := satisfyQuery[In](ctx); input != nil {
-				handleI(input, ctx, fn)
-			}
-
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Delete(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Delete(apiResource, url, HandleI(fn), name, opts...)
 }
 
 func DeleteO[Out any](apiResource, url string, fn HandleFnO[Out], name string, opts ...Opts) {
-	assertUnsupportedUseWithInput(opts)
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerVarO(apiResource, fullPath(url), http.MethodDelete, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerO[Out](apiResource, fullPath(url), http.MethodDelete, name)
-	}
-
-	action := func(ctx fiber.Ctx) error {
-		handleO(ctx, fn)
-		return nil
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Delete(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Delete(apiResource, url, HandleO(fn), name, opts...)
 }
 
 func DeleteIO[In any, Out any](apiResource, url string, fn HandleFnIO[In, Out], name string, opts ...Opts) {
-	swaglay.MustEmptyOrOneLength(opts)
-
-	if len(opts) > 0 && opts[0].Out != nil {
-		swaglay.RegisterHandlerIVarO[In](apiResource, fullPath(url), http.MethodDelete, name, opts[0].Out)
-	} else {
-		swaglay.RegisterHandlerIO[In, Out](apiResource, fullPath(url), http.MethodDelete, name)
-	}
-
-	var action fiber.Handler
-
-	if len(opts) > 0 && opts[0].UseWithInput {
-		opts = wrapSatisfyQueryInputMiddleware[In](opts)
-		action = func(ctx fiber.Ctx) error {
-			if input := ctx.Locals("input").(*In); input != nil {
-				handleIO(input, ctx, fn)
-			}
-			return nil
-		}
-	} else {
-		action = func(ctx fiber.Ctx) error {
-			if input := satisfyQuery[In](ctx); input != nil {
-				handleIO(input, ctx, fn)
-			}
-
-			return nil
-		}
-	}
-
-	handlers := make([]any, 0)
-	handlers = append(handlers, getMiddlewares(opts)...)
-	handlers = append(handlers, action)
-
-	Fiber.Delete(replacePath(url), handlers[0], handlers[1:]...)
+	newGlobalRegistrar().Delete(apiResource, url, HandleIO(fn), name, opts...)
 }
@@ -0,0 +1,46 @@
+package swaglay_fiber
+
+import (
+	"fmt"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_qf"
+	"github.com/gofiber/fiber/v3"
+	"reflect"
+)
+
+// parameterSources are the parameters bound to the fields of an input, by struct tag,
+// with the function that reads a parameter from the request.
+var parameterSources = []struct {
+	tag  string
+	name string
+	get  func(ctx fiber.Ctx, name string) string
+}{
+	{rest.PathTag, "path parameter", func(ctx fiber.Ctx, name string) string { return ctx.Params(name) }},
+	{rest.QueryTag, "query parameter", func(ctx fiber.Ctx, name string) string { return ctx.Query(name) }},
+	{rest.HeaderTag, "header", func(ctx fiber.Ctx, name string) string { return ctx.Get(name) }},
+	{rest.CookieTag, "cookie", func(ctx fiber.Ctx, name string) string { return ctx.Cookies(name) }},
+}
+
+// bindParameters binds the parameters of the request to the fields of the input with a path, query, header
+// or cookie tag, e.g. `path:"id"`. The fields are only bound from their parameters, so they're reset first,
+// and the fields of the parameters that aren't sent are left zero.
+func bindParameters(input any, ctx fiber.Ctx) error {
+	t := reflect.TypeOf(input).Elem()
+	v := reflect.ValueOf(input).Elem()
+	for _, source := range parameterSources {
+		for _, field := range swaglay_qf.GetParameterFields(t, source.tag) {
+			fieldValue := v.FieldByIndex(field.Index)
+			fieldValue.SetZero()
+
+			value := source.get(ctx, field.Name)
+			if value == "" && source.tag != rest.PathTag {
+				continue
+			}
+			if err := swaglay_qf.SetParameterValue(fieldValue, value); err != nil {
+				return fmt.Errorf("invalid %s %q: %w", source.name, field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package swaglay_fiber
 
 import (
+	"errors"
 	"github.com/KoNekoD/go-querymap/pkg/querymap"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/utils/v2"
@@ -8,7 +9,7 @@ import (
 	"net/url"
 )
 
-func satisfyQuery[DtoType any](ctx fiber.Ctx) *DtoType {
+func satisfyQuery[DtoType any](r *Registrar, ctx fiber.Ctx) *DtoType {
 	values := make(url.Values, ctx.RequestCtx().QueryArgs().Len())
 	ctx.RequestCtx().QueryArgs().All()(
 		func(key, value []byte) bool {
@@ -27,10 +28,7 @@ func satisfyQuery[DtoType any](ctx fiber.Ctx) *DtoType {
 
 	dto, err := querymap.FromValuesToStruct[DtoType](values)
 	if err != nil {
-		err = ctx.Status(http.StatusBadRequest).JSON(NewResponseErrorBody(ctx, err))
-		if err != nil {
-			OnHandleError(ctx, err)
-		}
+		r.sendInputError(ctx, http.StatusBadRequest, err)
 
 		return nil
 	}
@@ -39,11 +37,19 @@ func satisfyQuery[DtoType any](ctx fiber.Ctx) *DtoType {
 		setCtxIfNeeded(dto, ctx)
 	}
 
-	if err = FiberApp.Config().StructValidator.Validate(&dto); err != nil {
-		err = ctx.Status(http.StatusUnprocessableEntity).JSON(NewResponseErrorBody(ctx, err))
-		if err != nil {
-			OnHandleError(ctx, err)
+	if err = bindParameters(dto, ctx); err != nil {
+		r.sendInputError(ctx, http.StatusBadRequest, err)
+
+		return nil
+	}
+
+	if err = validateInput(ctx, dto); err != nil {
+		// The nested fields of the query are named in brackets.
+		var inputErr *inputValidationError
+		if errors.As(err, &inputErr) {
+			inputErr.query = true
 		}
+		r.sendInputError(ctx, http.StatusUnprocessableEntity, err)
 
 		return nil
 	}
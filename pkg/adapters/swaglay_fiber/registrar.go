@@ -0,0 +1,249 @@
+package swaglay_fiber
+
+import (
+	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v3"
+	"net/http"
+)
+
+// Registrar registers routes on a Fiber router and documents them in an API.
+// Each registrar carries its own API, error mapper and hooks, so controllers don't share state.
+// Example:
+//
+//	users := swaglay_fiber.NewRegistrar(app, api).Group("/api/users")
+//	users.Get("users", "/me", swaglay_fiber.HandleO(c.Me), "Me")
+//	users.Post("users", "/change-email", swaglay_fiber.HandleI(c.ChangeEmail), "Change email")
+type Registrar struct {
+	router fiber.Router
+	// app of the router, nil if the registrar is created from a group without WithApp.
+	app *fiber.App
+	api *rest.API
+
+	// newResponseError and newResponseErrorBody are nil for the default ones, that use the API of the route.
+	newResponseError     func(ctx fiber.Ctx, err error) (int, any)
+	newResponseErrorBody func(ctx fiber.Ctx, err error) any
+	onHandleError        func(ctx fiber.Ctx, err error)
+	translator           *ut.UniversalTranslator
+	validateRequests     bool
+}
+
+type RegistrarOpts func(r *Registrar)
+
+// WithErrorMapper sets the function that maps the errors of the handlers to the status and body of the response.
+// By default, the errors registered in the API are mapped to their responses, see rest.API.RegisterError,
+// then validation errors are 422, other errors are 500, and the body is created by WithErrorBody.
+func WithErrorMapper(f func(ctx fiber.Ctx, err error) (int, any)) RegistrarOpts {
+	return func(r *Registrar) {
+		r.newResponseError = f
+	}
+}
+
+// WithErrorBody sets the function that creates the body of error responses,
+// including the responses to requests that can't be bound to the input.
+// By default, it's problem details if the API is in the problem details mode, see rest.WithProblemDetails,
+// and DefaultResponseErrorBody otherwise.
+func WithErrorBody(f func(ctx fiber.Ctx, err error) any) RegistrarOpts {
+	return func(r *Registrar) {
+		r.newResponseErrorBody = f
+	}
+}
+
+// WithTranslator sets the translator of the messages of the validation errors, to the locale of the Accept-Language
+// header of the request, or to its fallback locale. Register the translations of the validator rules in it first.
+// Example:
+//
+//	english := en.New()
+//	translator := ut.New(english, english, fr.New())
+//	trans, _ := translator.GetTranslator("fr")
+//	_ = fr_translations.RegisterDefaultTranslations(validate, trans)
+//	registrar := swaglay_fiber.NewRegistrar(app, api, swaglay_fiber.WithTranslator(translator))
+func WithTranslator(translator *ut.UniversalTranslator) RegistrarOpts {
+	return func(r *Registrar) {
+		r.translator = translator
+	}
+}
+
+// WithRequestValidation validates the requests of the routes against their operations in the specification document,
+// before the middlewares and the handlers: the path, query, header and cookie parameters, and the body.
+// Requests with invalid parameters are answered with 400, and with an invalid body with 422.
+// It catches the drifts between the validator rules of the inputs and the documented schemas.
+func WithRequestValidation() RegistrarOpts {
+	return func(r *Registrar) {
+		r.validateRequests = true
+	}
+}
+
+// WithApp sets the app of the router of a registrar created from a group,
+// so that its configuration is known, e.g. DisableHeadAutoRegister.
+func WithApp(app *fiber.App) RegistrarOpts {
+	return func(r *Registrar) {
+		r.app = app
+	}
+}
+
+// WithOnHandleError sets the hook that is called with the errors of the handlers, e.g. to log them.
+func WithOnHandleError(f func(ctx fiber.Ctx, err error)) RegistrarOpts {
+	return func(r *Registrar) {
+		r.onHandleError = f
+	}
+}
+
+// NewRegistrar creates a registrar of routes on the router, documented in the API.
+func NewRegistrar(router fiber.Router, api *rest.API, opts ...RegistrarOpts) *Registrar {
+	app, _ := router.(*fiber.App)
+	r := &Registrar{
+		router:        router,
+		app:           app,
+		api:           api,
+		onHandleError: func(ctx fiber.Ctx, err error) {},
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// newGlobalRegistrar creates the registrar of the package level functions, from the package variables.
+// The variables are read when a request is handled, as they were before registrars existed.
+func newGlobalRegistrar() *Registrar {
+	app, ok := Fiber.(*fiber.App)
+	if !ok {
+		app = FiberApp
+	}
+	return &Registrar{
+		router:               Fiber,
+		app:                  app,
+		api:                  swaglay.Api,
+		newResponseError:     func(ctx fiber.Ctx, err error) (int, any) { return NewResponseError(ctx, err) },
+		newResponseErrorBody: func(ctx fiber.Ctx, err error) any { return NewResponseErrorBody(ctx, err) },
+		onHandleError:        func(ctx fiber.Ctx, err error) { OnHandleError(ctx, err) },
+	}
+}
+
+// Group creates a registrar for a group of routes with the prefix, and optional middlewares,
+// that shares the API, error mapper and hooks. Groups can be nested.
+// The prefix can have path parameters, e.g. /orgs/{orgId}, that the routes of the group document.
+func (r *Registrar) Group(prefix string, handlers ...any) *Registrar {
+	group := *r
+	group.router = r.router.Group(replacePath(prefix), handlers...)
+	return &group
+}
+
+// Router returns the Fiber router of the registrar.
+func (r *Registrar) Router() fiber.Router {
+	return r.router
+}
+
+// Api returns the API that the routes are documented in.
+func (r *Registrar) Api() *rest.API {
+	return r.api
+}
+
+// Get registers a GET route, the input of the handler is bound from the query string.
+// Fiber answers HEAD requests with the GET route, so the HEAD route is documented too,
+// unless the app is configured with DisableHeadAutoRegister. The app of a registrar created from a group is unknown,
+// unless it's set by WithApp, and Fiber answers HEAD requests by default.
+func (r *Registrar) Get(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodGet, apiResource, url, h, name, opts)
+
+	if r.app == nil || !r.app.Config().DisableHeadAutoRegister {
+		headName := name
+		if headName != "" {
+			headName = "Head " + headName
+		}
+		r.document(http.MethodHead, apiResource, url, h, headName, opts)
+	}
+}
+
+// Head registers a HEAD route, the input of the handler is bound from the query string.
+func (r *Registrar) Head(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodHead, apiResource, url, h, name, opts)
+}
+
+// Options registers an OPTIONS route, the input of the handler is bound from the query string.
+func (r *Registrar) Options(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodOptions, apiResource, url, h, name, opts)
+}
+
+// Post registers a POST route, the input of the handler is bound from the JSON body.
+func (r *Registrar) Post(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodPost, apiResource, url, h, name, opts)
+}
+
+// Put registers a PUT route, the input of the handler is bound from the JSON body.
+func (r *Registrar) Put(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodPut, apiResource, url, h, name, opts)
+}
+
+// Patch registers a PATCH route, the input of the handler is bound from the JSON body.
+func (r *Registrar) Patch(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodPatch, apiResource, url, h, name, opts)
+}
+
+// Delete registers a DELETE route, the input of the handler is bound from the query string.
+func (r *Registrar) Delete(apiResource, url string, h Handler, name string, opts ...Opts) {
+	r.add(http.MethodDelete, apiResource, url, h, name, opts)
+}
+
+func (r *Registrar) add(method, apiResource, url string, h Handler, name string, opts []Opts) {
+	swaglay.MustEmptyOrOneLength(opts)
+	if !h.hasInput() {
+		assertUnsupportedUseWithInput(opts)
+	}
+
+	r.document(method, apiResource, url, h, name, opts)
+
+	hasOutput := h.hasOutput() || (len(opts) > 0 && opts[0].Out != nil)
+	// The route responds as the API it's documented in, e.g. with its registered errors and problem details.
+	route := *r
+	route.api = r.getApi(opts)
+	action, opts := h.newAction(&route, isBodyInput(method), swaglay.SuccessStatus(method, hasOutput), opts)
+
+	handlers := make([]any, 0)
+	if r.validateRequests || (len(opts) > 0 && opts[0].ValidateRequest) {
+		handlers = append(handlers, route.newRequestValidator(route.api, method, fullPath(r.router, url)))
+	}
+	handlers = append(handlers, getMiddlewares(opts)...)
+	handlers = append(handlers, action)
+
+	r.router.Add([]string{method}, replacePath(url), handlers[0], handlers[1:]...)
+}
+
+// document registers the route of the handler in the API.
+func (r *Registrar) document(method, apiResource, url string, h Handler, name string, opts []Opts) {
+	path := fullPath(r.router, url)
+	api := r.getApi(opts)
+	h.document(api, apiResource, path, method, name, opts)
+	applyRouteOpts(api, method, path, opts)
+}
+
+// responseError maps the error to the status and body of the response with the error mapper, see WithErrorMapper.
+func (r *Registrar) responseError(ctx fiber.Ctx, err error) (int, any) {
+	if r.newResponseError != nil {
+		return r.newResponseError(ctx, err)
+	}
+	return defaultResponseError(r.api, ctx, err, r.responseErrorBody)
+}
+
+// responseErrorBody creates the body of an error response, see WithErrorBody.
+func (r *Registrar) responseErrorBody(ctx fiber.Ctx, err error) any {
+	if r.newResponseErrorBody != nil {
+		return r.newResponseErrorBody(ctx, err)
+	}
+	return defaultResponseErrorBody(r.api, r.translator, ctx, err)
+}
+
+func (r *Registrar) getApi(opts []Opts) *rest.API {
+	if len(opts) > 0 && opts[0].Api != nil {
+		return opts[0].Api
+	}
+
+	return r.api
+}
+
+// isBodyInput is whether the input of the method is bound from the body, or otherwise from the query string.
+func isBodyInput(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
@@ -0,0 +1,93 @@
+package swaglay_fiber
+
+import (
+	"errors"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_patch"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"net/http"
+)
+
+func init() {
+	// openapi3filter only decodes the common JSON content types, the merge patch bodies are JSON too.
+	openapi3filter.RegisterBodyDecoder(swaglay_patch.MergePatchContentType, openapi3filter.JSONBodyDecoder)
+}
+
+// newRequestValidator creates the middleware that validates the requests of the route against its operation
+// in the specification document of the API, with openapi3filter: the path, query, header and cookie parameters,
+// and the body. Requests with invalid parameters are answered with 400, and with an invalid body with 422.
+// The security requirements aren't checked.
+func (r *Registrar) newRequestValidator(api *rest.API, method, path string) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		spec, err := api.Spec()
+		if err != nil {
+			r.handleError(ctx, err)
+
+			return nil
+		}
+
+		pathItem := spec.Paths.Value(path)
+		if pathItem == nil {
+			return ctx.Next()
+		}
+		// Fiber answers HEAD requests with GET routes, whose HEAD operation may not be documented.
+		operation := pathItem.GetOperation(ctx.Method())
+		if operation == nil {
+			operation = pathItem.GetOperation(method)
+		}
+		if operation == nil {
+			return ctx.Next()
+		}
+
+		var request http.Request
+		if err = fasthttpadaptor.ConvertRequest(ctx.RequestCtx(), &request, true); err != nil {
+			r.handleError(ctx, err)
+
+			return nil
+		}
+
+		pathParams := make(map[string]string)
+		for _, parameters := range []openapi3.Parameters{pathItem.Parameters, operation.Parameters} {
+			for _, parameter := range parameters {
+				if parameter.Value != nil && parameter.Value.In == openapi3.ParameterInPath {
+					pathParams[parameter.Value.Name] = ctx.Params(parameter.Value.Name)
+				}
+			}
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    &request,
+			PathParams: pathParams,
+			Route: &routers.Route{
+				Spec:      spec,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    ctx.Method(),
+				Operation: operation,
+			},
+			Options: &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		if err = openapi3filter.ValidateRequest(ctx.Context(), input); err != nil {
+			r.sendInputError(ctx, requestValidationErrorStatus(err), err)
+
+			return nil
+		}
+
+		return ctx.Next()
+	}
+}
+
+// requestValidationErrorStatus is 422 for a request whose body is invalid, as for the bodies that can't be bound,
+// and 400 otherwise.
+func requestValidationErrorStatus(err error) int {
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) && requestErr.RequestBody != nil && requestErr.Parameter == nil {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusBadRequest
+}
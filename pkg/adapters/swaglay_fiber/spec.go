@@ -0,0 +1,67 @@
+package swaglay_fiber
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/gofiber/fiber/v3"
+	"net/http"
+	"time"
+)
+
+const (
+	JsonContentType = "application/json"
+	YamlContentType = "application/yaml"
+)
+
+// ServeSpec renders the specification of swaglay.Api and mounts it on the router
+// as openapi.json and openapi.yaml. Call it after all handlers are registered:
+// the documents are rendered once and later registrations are not reflected.
+func ServeSpec(router fiber.Router) error {
+	return ServeApiSpec(router, swaglay.Api)
+}
+
+// ServeApiSpec is like ServeSpec, but serves the specification of the given API,
+// e.g. on a separate group for each API created with swaglay.NewApi.
+func ServeApiSpec(router fiber.Router, api *rest.API) error {
+	if api == nil {
+		panic("Api is not setup")
+	}
+
+	jsonBytes, err := api.SpecJSON()
+	if err != nil {
+		return err
+	}
+
+	yamlBytes, err := api.SpecYAML()
+	if err != nil {
+		return err
+	}
+
+	lastModified := time.Now()
+
+	router.Get("/openapi.json", newSpecHandler(jsonBytes, JsonContentType, lastModified))
+	router.Get("/openapi.yaml", newSpecHandler(yamlBytes, YamlContentType, lastModified))
+
+	return nil
+}
+
+func newSpecHandler(body []byte, contentType string, lastModified time.Time) fiber.Handler {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	lastModifiedValue := lastModified.UTC().Format(http.TimeFormat)
+
+	return func(ctx fiber.Ctx) error {
+		ctx.Set(fiber.HeaderETag, etag)
+		ctx.Set(fiber.HeaderLastModified, lastModifiedValue)
+
+		if ctx.Fresh() {
+			return ctx.SendStatus(http.StatusNotModified)
+		}
+
+		ctx.Set(fiber.HeaderContentType, contentType)
+
+		return ctx.Send(body)
+	}
+}
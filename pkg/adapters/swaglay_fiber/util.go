@@ -3,16 +3,35 @@ package swaglay_fiber
 import (
 	"github.com/gofiber/fiber/v3"
 	"regexp"
+	"strings"
 )
 
 func replacePath(path string) string {
 	return regexp.MustCompile(`\{([^}]+)\}`).ReplaceAllString(path, ":$1")
 }
 
-func fullPath(path string) string {
-	if v, ok := Fiber.(*fiber.Group); ok {
-		return v.Prefix + path
+// documentedPath converts the Fiber parameters of the path back to OpenAPI ones, e.g. /orgs/:id -> /orgs/{id}.
+func documentedPath(path string) string {
+	return regexp.MustCompile(`:([A-Za-z0-9_]+)`).ReplaceAllString(path, "{$1}")
+}
+
+// fullPath is the path of the route registered on the router, including the prefixes of the groups.
+// The prefix of a nested group already includes the prefixes of its parents,
+// and its Fiber parameters are documented as OpenAPI ones.
+func fullPath(router fiber.Router, path string) string {
+	group, ok := router.(*fiber.Group)
+	if !ok || group.Prefix == "" {
+		return path
+	}
+	prefix := documentedPath(group.Prefix)
+
+	// Join the same way as Fiber does.
+	if path == "" {
+		return prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
 	}
 
-	return path
+	return strings.TrimRight(prefix, "/") + path
 }
@@ -0,0 +1,172 @@
+package swaglay_fiber
+
+import (
+	"errors"
+	"github.com/KoNekoD/swaglay/pkg/dtos"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// inputValidationError is the error of an input that fails validation, with the type of the input,
+// so that the fields of the validation errors are named as they're sent.
+type inputValidationError struct {
+	input reflect.Type
+	// query is whether the input is bound from the query string, whose nested fields are in brackets.
+	query bool
+	err   error
+}
+
+func (e *inputValidationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *inputValidationError) Unwrap() error {
+	return e.err
+}
+
+// newValidationErrorBody creates the body of the response to a validation error, see dtos.UnprocessableEntity,
+// with the messages of the fields translated to the locale of the request, if the translator has it.
+// ok is false if the error isn't a validation error.
+func newValidationErrorBody(ctx fiber.Ctx, err error, translator *ut.UniversalTranslator) (body dtos.UnprocessableEntity, ok bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return body, false
+	}
+
+	var input reflect.Type
+	var query bool
+	var inputErr *inputValidationError
+	if errors.As(err, &inputErr) {
+		input, query = inputErr.input, inputErr.query
+	}
+
+	var trans ut.Translator
+	if translator != nil {
+		trans, _ = translator.FindTranslator(getRequestLocales(ctx)...)
+	}
+
+	body.Error = err.Error()
+	for _, fieldError := range validationErrors {
+		message := fieldError.Error()
+		if trans != nil {
+			message = fieldError.Translate(trans)
+		}
+		field := getValidationFieldPath(input, fieldError.StructNamespace(), query)
+		if field == "" {
+			field = fieldError.Field()
+		}
+		body.Fields = append(body.Fields, dtos.FieldError{
+			Field:   field,
+			Tag:     fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: message,
+		})
+	}
+
+	return body, true
+}
+
+// getValidationFieldPath returns the path of a field in the request from its namespace in the input,
+// e.g. DataIn.Items[0].Name is items[0].name in a JSON body, or items[0][name] in a query.
+// The fields are named by their json tags, or by the names of the parameters they're bound to.
+// Without the type of the input, the fields are named as in the namespace.
+func getValidationFieldPath(t reflect.Type, namespace string, query bool) string {
+	segments := strings.Split(namespace, ".")
+	var path strings.Builder
+	for _, segment := range segments[1:] {
+		name, index, hasIndex := strings.Cut(segment, "[")
+
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+
+		fieldName := name
+		if t != nil && t.Kind() == reflect.Struct {
+			field, ok := t.FieldByName(name)
+			if !ok {
+				t = nil
+			} else {
+				t = field.Type
+				// The fields of embedded structs are inlined, unless they're named by a json tag.
+				if field.Anonymous && field.Tag.Get("json") == "" {
+					continue
+				}
+				fieldName = getRequestFieldName(field)
+			}
+		}
+
+		switch {
+		case path.Len() == 0:
+			path.WriteString(fieldName)
+		case query:
+			path.WriteString("[" + fieldName + "]")
+		default:
+			path.WriteString("." + fieldName)
+		}
+		if hasIndex {
+			path.WriteString("[" + index)
+		}
+	}
+
+	return path.String()
+}
+
+// getRequestFieldName returns the name of the field in the request,
+// the name of the parameter or form field it's bound to, or its JSON name.
+func getRequestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{rest.PathTag, rest.QueryTag, rest.HeaderTag, rest.CookieTag, rest.FormTag} {
+		if value, ok := field.Tag.Lookup(tag); ok {
+			if name, _, _ := strings.Cut(value, ","); name != "" {
+				return name
+			}
+		}
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
+
+// getRequestLocales returns the locales of the Accept-Language header of the request, in the order of preference,
+// as they're named by the translators, e.g. pt_BR, each followed by its base language, e.g. pt.
+func getRequestLocales(ctx fiber.Ctx) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+	var languages []language
+	for _, value := range strings.Split(ctx.Get(fiber.HeaderAcceptLanguage), ",") {
+		tag, params, _ := strings.Cut(value, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	var locales []string
+	for _, language := range languages {
+		locales = append(locales, strings.ReplaceAll(language.tag, "-", "_"))
+		if base, _, ok := strings.Cut(language.tag, "-"); ok {
+			locales = append(locales, base)
+		}
+	}
+
+	return locales
+}
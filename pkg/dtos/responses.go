@@ -4,7 +4,25 @@ type NotFound struct{}
 
 type BadRequest struct{}
 
-type UnprocessableEntity struct{}
+// UnprocessableEntity is the body of the responses to inputs that fail validation.
+type UnprocessableEntity struct {
+	// Error is the message of the validation error.
+	Error string `json:"error"`
+	// Fields are the fields that failed validation.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is a field of an input that failed a validator rule.
+type FieldError struct {
+	// Field is the path of the field in the request, e.g. items[0].name in a JSON body, or filter[name] in a query.
+	Field string `json:"field"`
+	// Tag is the validator rule, e.g. min.
+	Tag string `json:"tag"`
+	// Param is the parameter of the rule, e.g. 3 for min=3.
+	Param string `json:"param,omitempty"`
+	// Message explains the error, translated to the locale of the request if possible.
+	Message string `json:"message"`
+}
 
 type Created struct{}
 
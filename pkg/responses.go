@@ -0,0 +1,117 @@
+package swaglay
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// StatusTag is the struct tag of the fields of a Responses output, with the status of the response, e.g. `status:"202"`.
+const StatusTag = "status"
+
+// Responses marks an output with several typed responses, one by status. Embed it in a struct,
+// and declare each response as a field of a pointer, slice or map type tagged with its status.
+// The handler sets the field of the response it returns, whose status is used, and all of them are documented.
+// Example:
+//
+//	type GetOrderResponses struct {
+//		swaglay.Responses
+//		OK       *Order    `status:"200"`
+//		Accepted *Job      `status:"202"`
+//		Conflict *Conflict `status:"409"`
+//		NotFound *NotFound `status:"404"`
+//	}
+//
+//	func (c *OrderController) Get(ctx fiber.Ctx) (*GetOrderResponses, error) {
+//		return &GetOrderResponses{Accepted: job}, nil
+//	}
+type Responses struct{}
+
+func (Responses) responses() {}
+
+type responses interface {
+	responses()
+}
+
+// ResponseField is a response of a Responses output.
+type ResponseField struct {
+	// Status of the response.
+	Status int
+	// Index sequence of the field, see reflect.Value.FieldByIndex.
+	Index []int
+	// Type of the body of the response.
+	Type reflect.Type
+}
+
+var responseFieldsCache sync.Map
+
+// GetResponseFields returns the responses of the type if it's a Responses output, or a pointer to one, otherwise nil.
+// It panics if a response isn't a pointer, slice or map field with a valid status.
+func GetResponseFields(t reflect.Type) []ResponseField {
+	if t == nil || !t.Implements(reflect.TypeFor[responses]()) {
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if fields, ok := responseFieldsCache.Load(t); ok {
+		return fields.([]ResponseField)
+	}
+
+	var fields []ResponseField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(StatusTag)
+		if !ok {
+			continue
+		}
+		status, err := strconv.Atoi(tag)
+		if err != nil || status < 100 || status > 599 {
+			panic(fmt.Sprintf("invalid status %q of the response %s of %s", tag, field.Name, t))
+		}
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+		default:
+			panic(fmt.Sprintf("the response %s of %s must be a pointer, a slice or a map, got %s", field.Name, t, field.Type))
+		}
+		fields = append(fields, ResponseField{Status: status, Index: field.Index, Type: field.Type})
+	}
+	responseFieldsCache.Store(t, fields)
+
+	return fields
+}
+
+var errNoResponse = errors.New("no response is set")
+
+// GetResponse returns the status and the body of the response that is set in a Responses output.
+// It fails if none or several of them are set.
+func GetResponse(out any) (status int, body any, err error) {
+	v := reflect.ValueOf(out)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, nil, fmt.Errorf("%w in %T", errNoResponse, out)
+		}
+		v = v.Elem()
+	}
+
+	set := 0
+	for _, field := range GetResponseFields(v.Type()) {
+		value := v.FieldByIndex(field.Index)
+		if value.IsNil() {
+			continue
+		}
+		set++
+		status, body = field.Status, value.Interface()
+	}
+
+	switch set {
+	case 0:
+		return 0, nil, fmt.Errorf("%w in %T", errNoResponse, out)
+	case 1:
+		return status, body, nil
+	default:
+		return 0, nil, fmt.Errorf("%d responses are set in %T, only one can be", set, out)
+	}
+}
@@ -1,11 +1,16 @@
 package rest
 
 import (
+	"encoding/json"
+	"maps"
 	"net/http"
 	"reflect"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
 )
 
 type APIOpts func(*API)
@@ -22,12 +27,18 @@ func WithApplyCustomSchemaToType(f func(t reflect.Type, s *openapi3.Schema)) API
 // NewAPI creates a new API from the router.
 func NewAPI(name string, opts ...APIOpts) *API {
 	api := &API{
-		Name:       name,
-		KnownTypes: defaultKnownTypes,
-		Routes:     make(map[Pattern]MethodToRoute),
+		Name:           name,
+		OpenAPIVersion: OpenAPIVersion30,
+		Version:        "0.0.0",
+		// Validator rules are read from the default tag name of go-playground/validator.
+		ValidateTagName: "validate",
+		ValidateTags:    newDefaultValidateTags(),
+		KnownTypes:      defaultKnownTypes,
+		Routes:          make(map[Pattern]MethodToRoute),
+		// map of security scheme name to scheme.
+		SecuritySchemes: make(map[string]*openapi3.SecurityScheme),
 		// map of model name to schema.
-		models:   make(map[string]*openapi3.Schema),
-		comments: make(map[string]map[string]string),
+		models: make(map[string]*openapi3.Schema),
 	}
 	for _, o := range opts {
 		o(api)
@@ -56,8 +67,13 @@ type Route struct {
 	OperationID string
 	// Description for the route.
 	Description string
+	// Security requirements of the route, any of them grants access to the route.
+	Security []SecurityRequirement
 
 	RequestContentType []string
+
+	// api the route belongs to, nil for routes created by NewRoute.
+	api *API
 }
 
 // Params is a route parameter.
@@ -68,8 +84,10 @@ type Params struct {
 	// Query parameters are used in the querystring of the URL, e.g. /users/?sort={sortOrder} would
 	// have a name of "sort".
 	Query map[string]QueryParam
-
+	// Header parameters are sent in the headers of the request, e.g. X-Request-Id.
 	Header map[string]HeaderParam
+	// Cookie parameters are sent in the Cookie header of the request, e.g. session.
+	Cookie map[string]CookieParam
 }
 
 // PathParam is a paramater that's used in the path of a URL.
@@ -83,8 +101,11 @@ type PathParam struct {
 	Type PrimitiveType
 	// ApplyCustomSchema customises the OpenAPI schema for the path parameter.
 	ApplyCustomSchema func(s *openapi3.Parameter)
+	// StructTag of the field the parameter is bound to, used to apply the validator rules.
+	StructTag reflect.StructTag
 }
 
+// HeaderParam is a parameter that's sent in a header of the request.
 type HeaderParam struct {
 	// Description of the param.
 	Description string
@@ -93,10 +114,29 @@ type HeaderParam struct {
 	Regexp string
 	// Type of the param (string, number, integer, boolean).
 	Type PrimitiveType
-	// ApplyCustomSchema customises the OpenAPI schema for the path parameter.
+	// ApplyCustomSchema customises the OpenAPI schema for the header parameter.
 	ApplyCustomSchema func(s *openapi3.Parameter)
 
 	Required bool
+	// StructTag of the field the parameter is bound to, used to apply the validator rules.
+	StructTag reflect.StructTag
+}
+
+// CookieParam is a parameter that's sent in a cookie of the request.
+type CookieParam struct {
+	// Description of the param.
+	Description string
+	// Regexp is a regular expression used to validate the param.
+	// An empty string means that no validation is applied.
+	Regexp string
+	// Type of the param (string, number, integer, boolean).
+	Type PrimitiveType
+	// ApplyCustomSchema customises the OpenAPI schema for the cookie parameter.
+	ApplyCustomSchema func(s *openapi3.Parameter)
+	// Required sets whether the cookie must be sent.
+	Required bool
+	// StructTag of the field the parameter is bound to, used to apply the validator rules.
+	StructTag reflect.StructTag
 }
 
 // QueryParam is a paramater that's used in the querystring of a URL.
@@ -114,6 +154,8 @@ type QueryParam struct {
 	Type PrimitiveType
 	// ApplyCustomSchema customises the OpenAPI schema for the query parameter.
 	ApplyCustomSchema func(s *openapi3.Parameter)
+	// StructTag of the field the parameter is bound to, used to apply the validator rules.
+	StructTag reflect.StructTag
 }
 
 type PrimitiveType string
@@ -136,9 +178,29 @@ type Pattern string
 
 // API is a model of a REST API's routes, along with their
 // request and response types.
+// Routes and models can be registered, and the specification created, from several goroutines at once.
 type API struct {
+	// mu guards the routes, the models and the specification document.
+	mu sync.RWMutex
+
 	// Name of the API.
 	Name string
+	// OpenAPIVersion of the specification document, OpenAPIVersion30 by default.
+	OpenAPIVersion OpenAPIVersion
+	// Version of the API.
+	Version string
+	// Description of the API.
+	Description string
+	// TermsOfService is the URL of the terms of service of the API.
+	TermsOfService string
+	// Contact information of the API.
+	Contact *openapi3.Contact
+	// License of the API.
+	License *openapi3.License
+	// Servers that host the API.
+	Servers []Server
+	// ExternalDocs of the API.
+	ExternalDocs *openapi3.ExternalDocs
 	// Routes of the API.
 	// From patterns, to methods, to route.
 	Routes map[Pattern]MethodToRoute
@@ -163,27 +225,111 @@ type API struct {
 	//   Maps time.Time to a string.
 	KnownTypes map[reflect.Type]openapi3.Schema
 
-	// comments from the package. This can be cleared once the spec has been created.
-	comments map[string]map[string]string
-
 	// ApplyCustomSchemaToType callback to customise the OpenAPI specification for a given type.
 	// Apply customisation to a specific type by checking the t parameter.
 	// Apply customisations to all types by ignoring the t parameter.
 	ApplyCustomSchemaToType func(t reflect.Type, s *openapi3.Schema)
+
+	// SecuritySchemes that routes can require, by name.
+	SecuritySchemes map[string]*openapi3.SecurityScheme
+
+	// ValidateTagName is the name of the struct tag with the validator rules, e.g. "binding".
+	ValidateTagName string
+	// ValidateTags translate the validator rules into schema constraints, by rule name.
+	ValidateTags map[string]ValidateTagHandler
+
+	// UseProblemDetails documents the error responses as problem details, see WithProblemDetails.
+	UseProblemDetails bool
+
+	// errors map the errors of the handlers to responses, in the order they're registered.
+	errors []errorMapping
+
+	// spec is the specification document built by the last call to Spec,
+	// and specJSON and specYAML are its renderings, nil until they're created.
+	spec     *openapi3.T
+	specJSON []byte
+	specYAML []byte
 }
 
 // Merge route data into the existing configuration.
 // This is typically used by adapters, such as the chiadapter
 // to take information that the router already knows and add it
 // to the specification.
+// The route is merged at once, so a route built with NewRoute is never seen partially configured by Spec.
+// The existing configuration is kept, see AddRoute to overwrite it.
 func (api *API) Merge(r Route) {
-	toUpdate := api.Route(string(r.Method), string(r.Pattern))
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	toUpdate := api.route(string(r.Method), string(r.Pattern))
 	mergeMap(toUpdate.Params.Path, r.Params.Path)
 	mergeMap(toUpdate.Params.Query, r.Params.Query)
+	mergeMap(toUpdate.Params.Header, r.Params.Header)
+	mergeMap(toUpdate.Params.Cookie, r.Params.Cookie)
 	if toUpdate.Models.Request.Type == nil {
 		toUpdate.Models.Request = r.Models.Request
+		// The content types describe the request model, e.g. a form isn't sent as JSON, so they're taken with it.
+		if r.Models.Request.Type != nil {
+			toUpdate.RequestContentType = slices.Clone(r.RequestContentType)
+		}
 	}
 	mergeMap(toUpdate.Models.Responses, r.Models.Responses)
+	mergeMap(toUpdate.Models.FallbackResponses, r.Models.FallbackResponses)
+	if len(toUpdate.Security) == 0 {
+		toUpdate.Security = r.Security
+	}
+	if len(toUpdate.Tags) == 0 {
+		toUpdate.Tags = r.Tags
+	}
+	if toUpdate.OperationID == "" {
+		toUpdate.OperationID = r.OperationID
+	}
+	if toUpdate.Description == "" {
+		toUpdate.Description = r.Description
+	}
+	for _, contentType := range r.RequestContentType {
+		if !slices.Contains(toUpdate.RequestContentType, contentType) {
+			toUpdate.RequestContentType = append(toUpdate.RequestContentType, contentType)
+		}
+	}
+}
+
+// AddRoute adds the configuration of the route to the route of the API with the same method and pattern at once,
+// as the Route methods would: the params, responses and non-empty request model, security, operation ID
+// and description overwrite the existing ones, and the tags and request content types are appended.
+// Unlike Merge, which keeps the existing configuration, the route that's added last wins.
+// Example:
+//
+//	api.AddRoute(*rest.NewRoute(http.MethodGet, "/users/{id}").HasOperationID("getUser"))
+func (api *API) AddRoute(r Route) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	toUpdate := api.route(string(r.Method), string(r.Pattern))
+	maps.Copy(toUpdate.Params.Path, r.Params.Path)
+	maps.Copy(toUpdate.Params.Query, r.Params.Query)
+	maps.Copy(toUpdate.Params.Header, r.Params.Header)
+	maps.Copy(toUpdate.Params.Cookie, r.Params.Cookie)
+	if r.Models.Request.Type != nil {
+		toUpdate.Models.Request = r.Models.Request
+		// The content types describe the request model, e.g. a form isn't sent as JSON, so they're taken with it.
+		toUpdate.RequestContentType = slices.Clone(r.RequestContentType)
+	}
+	maps.Copy(toUpdate.Models.Responses, r.Models.Responses)
+	maps.Copy(toUpdate.Models.FallbackResponses, r.Models.FallbackResponses)
+	if len(r.Security) > 0 {
+		toUpdate.Security = r.Security
+	}
+	toUpdate.Tags = append(toUpdate.Tags, r.Tags...)
+	if r.OperationID != "" {
+		toUpdate.OperationID = r.OperationID
+	}
+	if r.Description != "" {
+		toUpdate.Description = r.Description
+	}
+	for _, contentType := range r.RequestContentType {
+		if !slices.Contains(toUpdate.RequestContentType, contentType) {
+			toUpdate.RequestContentType = append(toUpdate.RequestContentType, contentType)
+		}
+	}
 }
 
 func mergeMap[TKey comparable, TValue any](into, from map[TKey]TValue) {
@@ -195,17 +341,115 @@ func mergeMap[TKey comparable, TValue any](into, from map[TKey]TValue) {
 	}
 }
 
-// Spec creates an OpenAPI 3.0 specification document for the API.
+// Spec creates an OpenAPI specification document for the API.
+// The document is built once and reused until the routes or models change through Route, Merge
+// or RegisterModel, so it's shared between callers and must not be modified.
+// Call InvalidateSpec after changing the fields of the API directly.
 func (api *API) Spec() (spec *openapi3.T, err error) {
+	api.mu.RLock()
+	spec = api.spec
+	var types []reflect.Type
+	if spec == nil {
+		types = api.modelTypes()
+	}
+	knownTypes := api.KnownTypes
+	api.mu.RUnlock()
+	if spec != nil {
+		return spec, nil
+	}
+
+	preloadComments(knownTypes, types...)
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.buildSpec()
+}
+
+// modelTypes returns the types of the models of the routes and of the registered errors,
+// the caller must hold the lock.
+func (api *API) modelTypes() (types []reflect.Type) {
+	for _, methods := range api.Routes {
+		for _, route := range methods {
+			types = append(types, route.Models.Request.Type)
+			for _, model := range route.Models.Responses {
+				types = append(types, model.Type)
+			}
+			for _, model := range route.Models.FallbackResponses {
+				types = append(types, model.Type)
+			}
+		}
+	}
+	for _, mapping := range api.errors {
+		types = append(types, mapping.model.Type)
+	}
+	return types
+}
+
+// buildSpec returns the cached specification document, or builds it, the caller must hold the lock.
+func (api *API) buildSpec() (spec *openapi3.T, err error) {
+	if api.spec != nil {
+		return api.spec, nil
+	}
 	spec, err = api.createOpenAPI()
 	if err != nil {
 		return
 	}
+	api.spec = spec
 	return
 }
 
+// SpecJSON creates the OpenAPI specification document and renders it as JSON.
+func (api *API) SpecJSON() ([]byte, error) {
+	return api.renderSpec(&api.specJSON, json.Marshal)
+}
+
+// SpecYAML creates the OpenAPI specification document and renders it as YAML.
+func (api *API) SpecYAML() ([]byte, error) {
+	return api.renderSpec(&api.specYAML, yaml.Marshal)
+}
+
+// renderSpec renders the specification document with marshal, and caches it in rendered.
+func (api *API) renderSpec(rendered *[]byte, marshal func(any) ([]byte, error)) ([]byte, error) {
+	api.mu.RLock()
+	b := *rendered
+	api.mu.RUnlock()
+	if b != nil {
+		return b, nil
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if *rendered != nil {
+		return *rendered, nil
+	}
+	spec, err := api.buildSpec()
+	if err != nil {
+		return nil, err
+	}
+	*rendered, err = marshal(spec)
+	return *rendered, err
+}
+
+// InvalidateSpec discards the specification document, so that the next call to Spec rebuilds it.
+func (api *API) InvalidateSpec() {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.invalidateSpec()
+}
+
+func (api *API) invalidateSpec() {
+	api.spec, api.specJSON, api.specYAML = nil, nil, nil
+}
+
 // Route upserts a route to the API definition.
 func (api *API) Route(method, pattern string) (r *Route) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.route(method, pattern)
+}
+
+// route upserts the route, the caller must hold the lock.
+func (api *API) route(method, pattern string) (r *Route) {
+	api.invalidateSpec()
 	methodToRoute, ok := api.Routes[Pattern(pattern)]
 	if !ok {
 		methodToRoute = make(MethodToRoute)
@@ -213,24 +457,36 @@ func (api *API) Route(method, pattern string) (r *Route) {
 	}
 	route, ok := methodToRoute[Method(method)]
 	if !ok {
-		route = &Route{
-			RequestContentType: []string{"application/json"},
-			Method:             Method(method),
-			Pattern:            Pattern(pattern),
-			Models: Models{
-				Responses: make(map[int]Model),
-			},
-			Params: Params{
-				Path:   make(map[string]PathParam),
-				Query:  make(map[string]QueryParam),
-				Header: make(map[string]HeaderParam),
-			},
-		}
+		route = NewRoute(method, pattern)
+		route.api = api
 		methodToRoute[Method(method)] = route
 	}
 	return route
 }
 
+// NewRoute creates a route that doesn't belong to an API yet.
+// Configure it, then add it with API.AddRoute or API.Merge, so that it's added at once.
+// Example:
+//
+//	api.Merge(*rest.NewRoute(http.MethodGet, "/users/{id}").HasPathParameter("id", rest.PathParam{}))
+func NewRoute(method, pattern string) *Route {
+	return &Route{
+		RequestContentType: []string{"application/json"},
+		Method:             Method(method),
+		Pattern:            Pattern(pattern),
+		Models: Models{
+			Responses:         make(map[int]Model),
+			FallbackResponses: make(map[int]Model),
+		},
+		Params: Params{
+			Path:   make(map[string]PathParam),
+			Query:  make(map[string]QueryParam),
+			Header: make(map[string]HeaderParam),
+			Cookie: make(map[string]CookieParam),
+		},
+	}
+}
+
 // Get defines a GET request route for the given pattern.
 func (api *API) Get(pattern string) (r *Route) {
 	return api.Route(http.MethodGet, pattern)
@@ -276,60 +532,109 @@ func (api *API) Trace(pattern string) (r *Route) {
 	return api.Route(http.MethodTrace, pattern)
 }
 
+// lock locks the API of the route for a modification, which invalidates the specification document,
+// and returns the function that unlocks it.
+func (rm *Route) lock() (unlock func()) {
+	if rm.api == nil {
+		return func() {}
+	}
+	rm.api.mu.Lock()
+	rm.api.invalidateSpec()
+	return rm.api.mu.Unlock
+}
+
 // HasResponseModel configures a response for the route.
 // Example:
 //
 //	api.Get("/user").HasResponseModel(http.StatusOK, rest.ModelOf[User]())
 func (rm *Route) HasResponseModel(status int, response Model) *Route {
+	defer rm.lock()()
 	rm.Models.Responses[status] = response
 	return rm
 }
 
+// HasFallbackResponseModel configures a response for the route that an error registered with
+// RegisterError for the same status replaces, e.g. a generic response documented for every route.
+// Example:
+//
+//	api.Get("/user").HasFallbackResponseModel(http.StatusNotFound, rest.ModelOf[NotFound]())
+func (rm *Route) HasFallbackResponseModel(status int, response Model) *Route {
+	defer rm.lock()()
+	rm.Models.FallbackResponses[status] = response
+	return rm
+}
+
 // HasRequestModel configures the request model of the route.
+// The content types that the model declares with RequestContentTyper are added to the route,
+// and a form, a model with fields tagged form, is sent with the form content types instead of JSON.
 // Example:
 //
 //	api.Post("/user").HasRequestModel(http.StatusOK, rest.ModelOf[User]())
 func (rm *Route) HasRequestModel(request Model) *Route {
+	defer rm.lock()()
 	rm.Models.Request = request
+	if IsForm(request.Type) {
+		rm.RequestContentType = getFormContentTypes(request.Type)
+	}
+	for _, contentType := range getRequestContentTypes(request.Type) {
+		if !slices.Contains(rm.RequestContentType, contentType) {
+			rm.RequestContentType = append(rm.RequestContentType, contentType)
+		}
+	}
 	return rm
 }
 
 // HasPathParameter configures a path parameter for the route.
 func (rm *Route) HasPathParameter(name string, p PathParam) *Route {
+	defer rm.lock()()
 	rm.Params.Path[name] = p
 	return rm
 }
 
 // HasQueryParameter configures a query parameter for the route.
 func (rm *Route) HasQueryParameter(name string, q QueryParam) *Route {
+	defer rm.lock()()
 	rm.Params.Query[name] = q
 	return rm
 }
 
+// HasHeaderParameter configures a header parameter for the route.
 func (rm *Route) HasHeaderParameter(name string, h HeaderParam) *Route {
+	defer rm.lock()()
 	rm.Params.Header[name] = h
 	return rm
 }
 
+// HasCookieParameter configures a cookie parameter for the route.
+func (rm *Route) HasCookieParameter(name string, c CookieParam) *Route {
+	defer rm.lock()()
+	rm.Params.Cookie[name] = c
+	return rm
+}
+
 // HasTags sets the tags for the route.
 func (rm *Route) HasTags(tags []string) *Route {
+	defer rm.lock()()
 	rm.Tags = append(rm.Tags, tags...)
 	return rm
 }
 
 // HasOperationID sets the OperationID for the route.
 func (rm *Route) HasOperationID(operationID string) *Route {
+	defer rm.lock()()
 	rm.OperationID = operationID
 	return rm
 }
 
 // HasDescription sets the description for the route.
 func (rm *Route) HasDescription(description string) *Route {
+	defer rm.lock()()
 	rm.Description = description
 	return rm
 }
 
 func (rm *Route) HasRequestContentType(contentType string) *Route {
+	defer rm.lock()()
 	rm.RequestContentType = append(rm.RequestContentType, contentType)
 	return rm
 }
@@ -338,6 +643,8 @@ func (rm *Route) HasRequestContentType(contentType string) *Route {
 type Models struct {
 	Request   Model
 	Responses map[int]Model
+	// FallbackResponses are documented for the statuses that neither Responses nor a registered error documents.
+	FallbackResponses map[int]Model
 }
 
 // ModelOf creates a model of type T.
@@ -380,6 +687,23 @@ type CustomSchemaApplier interface {
 
 var _ CustomSchemaApplier = Model{}
 
+// RequestContentTyper is a request model that is sent with other content types than application/json,
+// e.g. a JSON Patch document.
+type RequestContentTyper interface {
+	RequestContentTypes() []string
+}
+
+// getRequestContentTypes returns the content types that the type declares with RequestContentTyper.
+func getRequestContentTypes(t reflect.Type) []string {
+	if t == nil {
+		return nil
+	}
+	if ct, ok := reflect.Zero(t).Interface().(RequestContentTyper); ok {
+		return ct.RequestContentTypes()
+	}
+	return nil
+}
+
 // Model is a model used in one or more routes.
 type Model struct {
 	Type reflect.Type
@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"flag"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"golang.org/x/tools/go/packages"
+)
+
+// getTypeComment returns the doc comment of a type, and whether the type is deprecated.
+func getTypeComment(pkgPath, typeName string) (description string, deprecated bool) {
+	return getComment(pkgPath, typeName)
+}
+
+// getTypeFieldComment returns the doc comment of a struct field, and whether the field is deprecated.
+func getTypeFieldComment(pkgPath, typeName, fieldName string) (description string, deprecated bool) {
+	return getComment(pkgPath, typeName+"."+fieldName)
+}
+
+// GetFieldComment returns the doc comment of the field of the struct type, e.g. to describe a parameter bound to it.
+func GetFieldComment(t reflect.Type, fieldName string) string {
+	if t.PkgPath() == "" {
+		return ""
+	}
+	return getPackageComments(t.PkgPath())[t.Name()+"."+fieldName]
+}
+
+func getComment(pkgPath, key string) (description string, deprecated bool) {
+	if pkgPath == "" {
+		return "", false
+	}
+	comment := getPackageComments(pkgPath)[key]
+	return comment, isMarkedAsDeprecated(comment)
+}
+
+// packageComments caches the comments by package path, since loading a package is slow,
+// and the comments don't change while the program runs.
+// Each package is loaded once, and packages are loaded concurrently.
+var packageComments sync.Map // map[string]*packageCommentsEntry
+
+type packageCommentsEntry struct {
+	once     sync.Once
+	comments map[string]string
+}
+
+func getPackageComments(pkgPath string) map[string]string {
+	value, _ := packageComments.LoadOrStore(pkgPath, &packageCommentsEntry{})
+	entry := value.(*packageCommentsEntry)
+	entry.once.Do(func() {
+		entry.comments = loadComments(pkgPath)
+	})
+	return entry.comments
+}
+
+// preloadComments loads the comments of the packages of the types, and of the types of their fields and elements,
+// so that the lock of the API isn't held while they're loaded. It stops where the schemas do: at the known types,
+// at the types of the standard library, whose comments aren't documented, and at unexported fields.
+func preloadComments(knownTypes map[reflect.Type]openapi3.Schema, types ...reflect.Type) {
+	visited := make(map[reflect.Type]bool)
+	var preload func(t reflect.Type)
+	preload = func(t reflect.Type) {
+		if t == nil || visited[t] {
+			return
+		}
+		visited[t] = true
+		if _, ok := knownTypes[t]; ok {
+			return
+		}
+		if t.PkgPath() != "" {
+			if isStandardPackage(t.PkgPath()) {
+				return
+			}
+			getPackageComments(t.PkgPath())
+		}
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			preload(t.Elem())
+		case reflect.Map:
+			preload(t.Key())
+			preload(t.Elem())
+		case reflect.Struct:
+			for i := range t.NumField() {
+				// Unexported embedded structs are walked, since their exported fields are promoted.
+				if f := t.Field(i); f.IsExported() || f.Anonymous {
+					preload(f.Type)
+				}
+			}
+		}
+	}
+	for _, t := range types {
+		preload(t)
+	}
+}
+
+// isStandardPackage reports whether the package is in the standard library.
+// Their paths have no dot in the first element, like the paths of some modules, so they're looked up in GOROOT.
+func isStandardPackage(pkgPath string) bool {
+	first, _, _ := strings.Cut(pkgPath, "/")
+	if strings.Contains(first, ".") {
+		return false
+	}
+	if build.Default.GOROOT == "" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(build.Default.GOROOT, "src", pkgPath))
+	return err == nil
+}
+
+// loadComments loads the doc comments of the types and struct fields declared in the package,
+// keyed by "Type" and "Type.Field".
+// Comments are only available if the source of the package can be loaded, otherwise none are returned.
+// The comments of the standard library aren't loaded, they document Go rather than the API.
+func loadComments(pkgPath string) map[string]string {
+	comments := make(map[string]string)
+	if isStandardPackage(pkgPath) {
+		return comments
+	}
+	config := &packages.Config{
+		Mode: packages.NeedName |
+			packages.NeedFiles |
+			packages.NeedSyntax,
+		// Only look in test files if a test is in progress.
+		Tests: flag.Lookup("test.v") != nil,
+		Fset:  token.NewFileSet(),
+	}
+	pkgs, err := packages.Load(config, pkgPath)
+	if err != nil {
+		return comments
+	}
+	for _, p := range pkgs {
+		for _, syn := range p.Syntax {
+			for _, d := range syn.Decls {
+				gd, ok := d.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, sp := range gd.Specs {
+					ts := sp.(*ast.TypeSpec)
+					doc := ts.Doc
+					if doc == nil && len(gd.Specs) == 1 {
+						doc = gd.Doc
+					}
+					setComment(comments, ts.Name.Name, doc, ts.Comment)
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, f := range st.Fields.List {
+						for _, name := range f.Names {
+							setComment(comments, ts.Name.Name+"."+name.Name, f.Doc, f.Comment)
+						}
+					}
+				}
+			}
+		}
+	}
+	return comments
+}
+
+func setComment(comments map[string]string, key string, doc, lineComment *ast.CommentGroup) {
+	if doc == nil {
+		doc = lineComment
+	}
+	if doc == nil {
+		return
+	}
+	if text := strings.TrimSpace(doc.Text()); text != "" {
+		comments[key] = text
+	}
+}
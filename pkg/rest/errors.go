@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ErrorResponse is the response to the errors of a registered error, see API.RegisterError and RegisterErrorType.
+type ErrorResponse struct {
+	// Status of the response.
+	Status int
+	// Description of the response in the specification document, and title of its problem details.
+	Description string
+	// Type is a URI reference that identifies the problem type of its problem details, see WithProblemDetails.
+	Type string
+	// Body of the response, that documents its content.
+	// If nil, the body of the response to an error type is the error itself, documented by the type,
+	// and the body of the response to a sentinel error is created by the adapter, documented without content.
+	Body any
+	// Methods are the methods of the operations the response is documented in, e.g. http.MethodGet.
+	// If empty, it's documented in the operations of every method but HEAD and OPTIONS.
+	Methods []string
+}
+
+// errorMapping maps the errors that match to a response.
+type errorMapping struct {
+	response ErrorResponse
+	// match returns the error of the chain of err that matches, nil if none.
+	match func(err error) error
+	// model documents the body of the response, without type if it has no content.
+	model Model
+}
+
+// RegisterError maps the errors that match the target, see errors.Is, to the response.
+// The response is documented in the operations of the API, see ErrorResponse.Methods, unless they declare
+// a response of the same status, and adapters respond with it to the errors of the handlers.
+// Errors are matched in the order they're registered.
+// Example:
+//
+//	api.RegisterError(ErrUserNotFound, rest.ErrorResponse{Status: http.StatusNotFound, Description: "User not found"})
+func (api *API) RegisterError(target error, response ErrorResponse) {
+	mapping := errorMapping{
+		response: response,
+		match: func(err error) error {
+			if errors.Is(err, target) {
+				return target
+			}
+			return nil
+		},
+	}
+	if response.Body != nil {
+		mapping.model = ModelOfReflect(response.Body)
+	}
+	api.registerErrorMapping(mapping)
+}
+
+// RegisterErrorType maps the errors of the type E, see errors.As, to the response.
+// Unless the response has a body, the error is the body of the response, encoded as JSON.
+// Example:
+//
+//	rest.RegisterErrorType[*ConflictError](api, rest.ErrorResponse{Status: http.StatusConflict, Description: "Conflict"})
+func RegisterErrorType[E error](api *API, response ErrorResponse) {
+	mapping := errorMapping{
+		response: response,
+		match: func(err error) error {
+			var target E
+			if errors.As(err, &target) {
+				return target
+			}
+			return nil
+		},
+		model: modelFromType(reflect.TypeFor[E]()),
+	}
+	if response.Body != nil {
+		mapping.model = ModelOfReflect(response.Body)
+	}
+	api.registerErrorMapping(mapping)
+}
+
+func (api *API) registerErrorMapping(mapping errorMapping) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.invalidateSpec()
+	api.errors = append(api.errors, mapping)
+}
+
+// MapError returns the response to the error, of the first registered error it matches, and its body.
+// The body is nil if the adapter creates it, see ErrorResponse.Body. ok is false if the error isn't registered.
+func (api *API) MapError(err error) (response ErrorResponse, body any, ok bool) {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+	for _, mapping := range api.errors {
+		matched := mapping.match(err)
+		if matched == nil {
+			continue
+		}
+		if mapping.response.Body != nil {
+			return mapping.response, mapping.response.Body, true
+		}
+		if mapping.model.Type != nil {
+			return mapping.response, matched, true
+		}
+		return mapping.response, nil, true
+	}
+	return ErrorResponse{}, nil, false
+}
+
+// addErrorResponses documents the registered errors of the method in the responses of the operation,
+// the first registered error of a status documents it. The responses the operation declares aren't overwritten,
+// its fallback responses are added after them.
+// The caller must hold the lock of the API.
+func (api *API) addErrorResponses(op *openapi3.Operation, method Method) error {
+	for _, mapping := range api.errors {
+		status := mapping.response.Status
+		if !mapping.documents(method) || (op.Responses != nil && op.Responses.Status(status) != nil) {
+			continue
+		}
+
+		if api.UseProblemDetails {
+			resp, err := api.newProblemResponse(mapping.response.Description, mapping.model)
+			if err != nil {
+				return err
+			}
+			op.AddResponse(status, resp)
+			continue
+		}
+
+		resp := openapi3.NewResponse().WithDescription(mapping.response.Description)
+		if mapping.model.Type != nil {
+			name, schema, err := api.registerModel(mapping.model)
+			if err != nil {
+				return err
+			}
+			resp.WithJSONSchemaRef(getSchemaReferenceOrValue(name, schema))
+		}
+		op.AddResponse(status, resp)
+	}
+	return nil
+}
+
+// documents reports whether the response is documented in the operations of the method.
+func (mapping errorMapping) documents(method Method) bool {
+	if len(mapping.response.Methods) == 0 {
+		return method != http.MethodHead && method != http.MethodOptions
+	}
+	return slices.Contains(mapping.response.Methods, string(method))
+}
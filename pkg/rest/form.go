@@ -0,0 +1,240 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// FormTag is the struct tag of the fields of an input that are bound from a form body, e.g. `form:"avatar"`.
+// The input is documented as a multipart/form-data body, and as an application/x-www-form-urlencoded body
+// too if it has no files. The form tag names the field instead of the json tag.
+const FormTag = "form"
+
+// Struct tags of the limits of a File field, e.g. `accept:"image/png,image/jpeg" maxSize:"2MB"`.
+// The size is in bytes, or in KB, MB or GB, that are multiples of 1024.
+// The media types can end with a wildcard, e.g. image/*.
+const (
+	AcceptTag  = "accept"
+	MaxSizeTag = "maxSize"
+)
+
+const (
+	MultipartFormContentType  = "multipart/form-data"
+	URLEncodedFormContentType = "application/x-www-form-urlencoded"
+)
+
+var (
+	// ErrFileTooLarge is returned by FileLimits.Check for a file larger than the maximum size.
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrUnsupportedFileType is returned by FileLimits.Check for a file of a media type that isn't accepted.
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+)
+
+// File is an uploaded file of a multipart/form-data body, bound to a field tagged form.
+// It's documented as a binary string.
+// Example:
+//
+//	type UploadAvatar struct {
+//		Avatar rest.File `form:"avatar" accept:"image/png,image/jpeg" maxSize:"2MB"`
+//		Alt    string    `form:"alt"`
+//	}
+type File struct {
+	header *multipart.FileHeader
+}
+
+var fileType = reflect.TypeFor[File]()
+
+// NewFile creates a File of the header of a multipart file.
+func NewFile(header *multipart.FileHeader) File {
+	return File{header: header}
+}
+
+// Header returns the header of the multipart file, nil if no file was uploaded.
+func (f File) Header() *multipart.FileHeader {
+	return f.header
+}
+
+// Filename returns the name of the file on the client.
+func (f File) Filename() string {
+	if f.header == nil {
+		return ""
+	}
+	return f.header.Filename
+}
+
+// Size returns the size of the file in bytes.
+func (f File) Size() int64 {
+	if f.header == nil {
+		return 0
+	}
+	return f.header.Size
+}
+
+// ContentType returns the media type of the file that the client sent, without its parameters.
+func (f File) ContentType() string {
+	if f.header == nil {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(f.header.Header.Get("Content-Type"))
+	return mediaType
+}
+
+// DetectContentType returns the media type of the file detected from its first 512 bytes,
+// see http.DetectContentType, without its parameters.
+func (f File) DetectContentType() (string, error) {
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	b := make([]byte, 512)
+	n, err := io.ReadFull(file, b)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(b[:n]))
+	return mediaType, nil
+}
+
+// Open opens the file.
+func (f File) Open() (multipart.File, error) {
+	if f.header == nil {
+		return nil, errors.New("no file was uploaded")
+	}
+	return f.header.Open()
+}
+
+// FileLimits are the limits of a File field, set by its accept and maxSize tags.
+type FileLimits struct {
+	// MaxSize of the file in bytes, 0 for no limit.
+	MaxSize int64
+	// Accept are the accepted media types, any if empty.
+	Accept []string
+}
+
+// GetFileLimits returns the limits of the File field with the struct tag.
+func GetFileLimits(tag reflect.StructTag) (limits FileLimits, err error) {
+	if maxSize, ok := tag.Lookup(MaxSizeTag); ok {
+		if limits.MaxSize, err = parseSize(maxSize); err != nil {
+			return limits, fmt.Errorf("invalid %s tag %q: %w", MaxSizeTag, maxSize, err)
+		}
+	}
+	if accept := tag.Get(AcceptTag); accept != "" {
+		for _, mediaType := range strings.Split(accept, ",") {
+			limits.Accept = append(limits.Accept, strings.TrimSpace(mediaType))
+		}
+	}
+	return limits, nil
+}
+
+// Check returns ErrFileTooLarge or ErrUnsupportedFileType, wrapped, if the file exceeds the limits.
+// The media type of the file is detected from its content, the one the client sent isn't trusted.
+func (l FileLimits) Check(f File) error {
+	if l.MaxSize > 0 && f.Size() > l.MaxSize {
+		return fmt.Errorf("%w: %q is %d bytes, the maximum is %d bytes", ErrFileTooLarge, f.Filename(), f.Size(), l.MaxSize)
+	}
+	if len(l.Accept) == 0 {
+		return nil
+	}
+	contentType, err := f.DetectContentType()
+	if err != nil {
+		return err
+	}
+	for _, accepted := range l.Accept {
+		if prefix, ok := strings.CutSuffix(accepted, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return nil
+			}
+		} else if contentType == accepted {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is %q, accepted types are %s", ErrUnsupportedFileType, f.Filename(), contentType, strings.Join(l.Accept, ", "))
+}
+
+// parseSize parses a size in bytes, or in KB, MB or GB.
+func parseSize(s string) (int64, error) {
+	multiplier := int64(1)
+	for i, unit := range []string{"KB", "MB", "GB"} {
+		if number, ok := strings.CutSuffix(s, unit); ok {
+			s, multiplier = number, 1<<(10*(i+1))
+			break
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(s, "B")), 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.New("expected a size like 512, 100KB or 2MB")
+	}
+	return size * multiplier, nil
+}
+
+// applyFileLimits documents the maximum size of a File field as the maximum length of its binary string,
+// or of the strings of a slice of files.
+func applyFileLimits(s *openapi3.Schema, tag reflect.StructTag) error {
+	limits, err := GetFileLimits(tag)
+	if err != nil || limits.MaxSize == 0 {
+		return err
+	}
+	if s.Type.Is(openapi3.TypeArray) && s.Items != nil && s.Items.Value != nil {
+		s = s.Items.Value
+	}
+	maxLength := uint64(limits.MaxSize)
+	s.MaxLength = &maxLength
+	return nil
+}
+
+// IsForm reports whether the type is an input with fields tagged form, that is bound from a form body.
+func IsForm(t reflect.Type) bool {
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, jf := range structFields(t, formTag) {
+		if _, ok := jf.field.Tag.Lookup(FormTag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFileType reports whether the type is File, a pointer to File or a slice of them.
+func IsFileType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == fileType
+}
+
+// getFormContentTypes returns the content types of a form, files are only sent in multipart/form-data bodies.
+func getFormContentTypes(t reflect.Type) []string {
+	for _, jf := range requestBodyFields(t) {
+		if IsFileType(jf.field.Type) {
+			return []string{MultipartFormContentType}
+		}
+	}
+	return []string{MultipartFormContentType, URLEncodedFormContentType}
+}
+
+// getFormEncoding documents the media types that the File fields of a multipart form accept.
+func getFormEncoding(t reflect.Type) map[string]*openapi3.Encoding {
+	encoding := make(map[string]*openapi3.Encoding)
+	for _, jf := range requestBodyFields(t) {
+		if accept := jf.field.Tag.Get(AcceptTag); accept != "" && IsFileType(jf.field.Type) {
+			limits, _ := GetFileLimits(jf.field.Tag)
+			encoding[jf.name] = &openapi3.Encoding{ContentType: strings.Join(limits.Accept, ", ")}
+		}
+	}
+	if len(encoding) == 0 {
+		return nil
+	}
+	return encoding
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Server is a server that hosts the API.
+type Server struct {
+	// URL of the server, which can be templated with variables, e.g. https://{env}.example.com/api
+	URL string
+	// Description of the server.
+	Description string
+	// Variables used in the URL template, by name.
+	Variables map[string]ServerVariable
+}
+
+// ServerVariable is a variable used in a templated server URL.
+type ServerVariable struct {
+	// Default value of the variable.
+	Default string
+	// Enum restricts the variable to the given values.
+	Enum []string
+	// Description of the variable.
+	Description string
+}
+
+// WithVersion sets the version of the API, 0.0.0 by default.
+func WithVersion(version string) APIOpts {
+	return func(api *API) {
+		api.Version = version
+	}
+}
+
+// WithAPIDescription sets the description of the API.
+func WithAPIDescription(description string) APIOpts {
+	return func(api *API) {
+		api.Description = description
+	}
+}
+
+// WithTermsOfService sets the URL of the terms of service of the API.
+func WithTermsOfService(url string) APIOpts {
+	return func(api *API) {
+		api.TermsOfService = url
+	}
+}
+
+// WithContact sets the contact information of the API, any of the values can be empty.
+func WithContact(name, url, email string) APIOpts {
+	return func(api *API) {
+		api.Contact = &openapi3.Contact{Name: name, URL: url, Email: email}
+	}
+}
+
+// WithLicense sets the license of the API.
+func WithLicense(name, url string) APIOpts {
+	return func(api *API) {
+		api.License = &openapi3.License{Name: name, URL: url}
+	}
+}
+
+// WithExternalDocs sets the external documentation of the API.
+func WithExternalDocs(url, description string) APIOpts {
+	return func(api *API) {
+		api.ExternalDocs = &openapi3.ExternalDocs{URL: url, Description: description}
+	}
+}
+
+// WithServer adds a server that hosts the API.
+// Example:
+//
+//	rest.WithServer(rest.Server{
+//		URL: "https://{env}.example.com",
+//		Variables: map[string]rest.ServerVariable{
+//			"env": {Default: "prod", Enum: []string{"prod", "staging"}},
+//		},
+//	})
+func WithServer(server Server) APIOpts {
+	return func(api *API) {
+		api.Servers = append(api.Servers, server)
+	}
+}
+
+func (api *API) newInfo() *openapi3.Info {
+	return &openapi3.Info{
+		Title:          api.Name,
+		Description:    api.Description,
+		TermsOfService: api.TermsOfService,
+		Contact:        api.Contact,
+		License:        api.License,
+		Version:        api.Version,
+		Extensions:     map[string]interface{}{},
+	}
+}
+
+func (api *API) newServers() (servers openapi3.Servers) {
+	for _, s := range api.Servers {
+		server := &openapi3.Server{
+			URL:         s.URL,
+			Description: s.Description,
+		}
+		if len(s.Variables) > 0 {
+			server.Variables = make(map[string]*openapi3.ServerVariable, len(s.Variables))
+			for name, v := range s.Variables {
+				server.Variables[name] = &openapi3.ServerVariable{
+					Default:     v.Default,
+					Enum:        v.Enum,
+					Description: v.Description,
+				}
+			}
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
@@ -0,0 +1,239 @@
+package rest
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Struct tags of the fields of an input that are bound from parameters, e.g. `path:"id"`, `query:"notify"`,
+// `header:"X-Request-Id"` or `cookie:"session"`. They're left out of the schema of the request body of the input,
+// as the parameters are documented instead.
+const (
+	PathTag   = "path"
+	QueryTag  = "query"
+	HeaderTag = "header"
+	CookieTag = "cookie"
+)
+
+// BodyTag is the struct tag of the field of a composite input that the request body is bound to, e.g. `body:""`.
+// The other fields of a composite input are bound from parameters, whatever the method is.
+const BodyTag = "body"
+
+// IsParameterField reports whether the field is bound from a path, query, header or cookie parameter.
+func IsParameterField(sf reflect.StructField) bool {
+	for _, tag := range []string{PathTag, QueryTag, HeaderTag, CookieTag} {
+		if _, ok := sf.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonField is a field of a struct as it's encoded by encoding/json.
+type jsonField struct {
+	// name of the JSON property.
+	name string
+	// tagged is whether the name comes from the json tag.
+	tagged bool
+	// index sequence of the field, see reflect.Type.FieldByIndex.
+	index []int
+	// field is the struct field.
+	field reflect.StructField
+	// parent is the struct type that declares the field, which can be an embedded struct.
+	parent reflect.Type
+	// omitEmpty is whether the field has the omitempty or omitzero option.
+	omitEmpty bool
+	// asString is whether the field has the string option.
+	asString bool
+	// optional is whether the field is promoted through an embedded pointer,
+	// which omits the field when the pointer is nil.
+	optional bool
+}
+
+// jsonFields returns the fields of the struct type that are encoded by encoding/json, in field order.
+// It follows the rules of encoding/json: fields of embedded structs are promoted, and when several fields
+// have the same name, the least nested one wins, then the tagged one, and otherwise all of them are dropped.
+func jsonFields(t reflect.Type) []jsonField {
+	return structFields(t, jsonTag)
+}
+
+// requestBodyFields returns the fields of the struct type that are bound from a request body,
+// which are the fields of jsonFields but the fields bound from parameters. The fields of a form, see IsForm,
+// are named by their form tag instead.
+func requestBodyFields(t reflect.Type) []jsonField {
+	tagOf := jsonTag
+	if IsForm(t) {
+		tagOf = formTag
+	}
+	return structFields(t, func(sf reflect.StructField) (string, bool) {
+		if IsParameterField(sf) {
+			return "", false
+		}
+		return tagOf(sf)
+	})
+}
+
+// jsonTag returns the json tag of the field, the field is encoded unless the tag is "-".
+func jsonTag(sf reflect.StructField) (tag string, ok bool) {
+	tag = sf.Tag.Get("json")
+	return tag, tag != "-"
+}
+
+// formTag returns the form tag of the field, or its json tag if it has none.
+func formTag(sf reflect.StructField) (tag string, ok bool) {
+	if tag, ok = sf.Tag.Lookup(FormTag); ok {
+		return tag, tag != "-"
+	}
+	return jsonTag(sf)
+}
+
+// structFields returns the fields of the struct type by the rules of jsonFields,
+// with the tag that tagOf returns for each field instead of the json tag. The fields it isn't ok for are left out.
+func structFields(t reflect.Type, tagOf func(sf reflect.StructField) (tag string, ok bool)) []jsonField {
+	type embedded struct {
+		typ      reflect.Type
+		index    []int
+		optional bool
+	}
+
+	var current []embedded
+	next := []embedded{{typ: t}}
+
+	// Count of embedded structs of each type at the current and the next depth.
+	var count map[reflect.Type]int
+	nextCount := map[reflect.Type]int{}
+
+	visited := map[reflect.Type]bool{}
+
+	var fields []jsonField
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					// Exported fields of unexported embedded structs are still promoted.
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag, ok := tagOf(sf)
+				if !ok {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				options := strings.Split(opts, ",")
+
+				index := append(slices.Clone(e.index), i)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				// Embedded structs without a name in the tag are promoted into the parent.
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, embedded{
+							typ:      ft,
+							index:    index,
+							optional: e.optional || sf.Type.Kind() == reflect.Pointer,
+						})
+					}
+					continue
+				}
+
+				field := jsonField{
+					name:      name,
+					tagged:    name != "",
+					index:     index,
+					field:     sf,
+					parent:    e.typ,
+					omitEmpty: slices.Contains(options, "omitempty") || slices.Contains(options, "omitzero"),
+					asString:  slices.Contains(options, "string") && isJSONStringKind(ft.Kind()),
+					optional:  e.optional,
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+				fields = append(fields, field)
+				if count[e.typ] > 1 {
+					// The struct is embedded more than once at this depth,
+					// so its fields conflict with each other and are dropped.
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(fields, func(a, b jsonField) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := len(a.index) - len(b.index); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.index, b.index)
+	})
+
+	dominant := fields[:0]
+	for i, advance := 0, 0; i < len(fields); i += advance {
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fields[i].name {
+				break
+			}
+		}
+		if advance == 1 {
+			dominant = append(dominant, fields[i])
+			continue
+		}
+		// The fields are sorted by depth and then tags, so the first one wins, unless it's tied with the second one.
+		if len(fields[i].index) == len(fields[i+1].index) && fields[i].tagged == fields[i+1].tagged {
+			continue
+		}
+		dominant = append(dominant, fields[i])
+	}
+
+	slices.SortFunc(dominant, func(a, b jsonField) int {
+		return slices.Compare(a.index, b.index)
+	})
+
+	return dominant
+}
+
+// isJSONStringKind is whether the string option of the json tag applies to the kind.
+func isJSONStringKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	default:
+		return false
+	}
+}
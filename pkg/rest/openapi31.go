@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the API emits.
+type OpenAPIVersion string
+
+const (
+	OpenAPIVersion30 OpenAPIVersion = "3.0.0"
+	OpenAPIVersion31 OpenAPIVersion = "3.1.0"
+)
+
+// openAPI31Keywords are the JSON Schema keywords that are stored as schema extensions,
+// since the openapi3 package only models the OpenAPI 3.0 schema object.
+var openAPI31Keywords = []string{"examples", "const", "$schema"}
+
+// WithOpenAPIVersion sets the version of the OpenAPI specification document.
+// OpenAPIVersion30 is used by default.
+func WithOpenAPIVersion(version OpenAPIVersion) APIOpts {
+	return func(api *API) {
+		api.OpenAPIVersion = version
+	}
+}
+
+// WithExamples sets the examples of the schema.
+// Only valid in OpenAPI 3.1 documents, use the Example field of the schema for OpenAPI 3.0.
+func WithExamples(examples ...any) ModelOpts {
+	return func(s *openapi3.Schema) {
+		setSchemaExtension(s, "examples", examples)
+	}
+}
+
+// WithConst restricts the schema to a single value.
+// Only valid in OpenAPI 3.1 documents.
+func WithConst(value any) ModelOpts {
+	return func(s *openapi3.Schema) {
+		setSchemaExtension(s, "const", value)
+	}
+}
+
+// WithSchemaDialect sets the $schema keyword of the schema, e.g. https://json-schema.org/draft/2020-12/schema
+// Only valid in OpenAPI 3.1 documents.
+func WithSchemaDialect(uri string) ModelOpts {
+	return func(s *openapi3.Schema) {
+		setSchemaExtension(s, "$schema", uri)
+	}
+}
+
+func setSchemaExtension(s *openapi3.Schema, key string, value any) {
+	if s.Extensions == nil {
+		s.Extensions = make(map[string]any)
+	}
+	s.Extensions[key] = value
+}
+
+// toOpenAPI31 rewrites a validated OpenAPI 3.0 document into an OpenAPI 3.1 document.
+// The schemas are copied, so that the registered models are left in the OpenAPI 3.0 form.
+func toOpenAPI31(spec *openapi3.T) {
+	spec.OpenAPI = string(OpenAPIVersion31)
+
+	converted := make(map[*openapi3.Schema]*openapi3.Schema)
+
+	for _, ref := range spec.Components.Schemas {
+		convertSchemaRefTo31(ref, converted)
+	}
+
+	for _, path := range spec.Paths.Map() {
+		for _, op := range path.Operations() {
+			for _, param := range op.Parameters {
+				convertSchemaRefTo31(param.Value.Schema, converted)
+			}
+			if op.RequestBody != nil {
+				convertContentTo31(op.RequestBody.Value.Content, converted)
+			}
+			if op.Responses == nil {
+				continue
+			}
+			for _, resp := range op.Responses.Map() {
+				convertContentTo31(resp.Value.Content, converted)
+				for _, header := range resp.Value.Headers {
+					convertSchemaRefTo31(header.Value.Schema, converted)
+				}
+			}
+		}
+	}
+}
+
+func convertContentTo31(content openapi3.Content, converted map[*openapi3.Schema]*openapi3.Schema) {
+	for _, mediaType := range content {
+		convertSchemaRefTo31(mediaType.Schema, converted)
+	}
+}
+
+func convertSchemaRefTo31(ref *openapi3.SchemaRef, converted map[*openapi3.Schema]*openapi3.Schema) {
+	if ref == nil || ref.Value == nil {
+		return
+	}
+	ref.Value = convertSchemaTo31(ref.Value, converted)
+}
+
+func convertSchemaTo31(s *openapi3.Schema, converted map[*openapi3.Schema]*openapi3.Schema) *openapi3.Schema {
+	if c, ok := converted[s]; ok {
+		return c
+	}
+
+	c := *s
+	converted[s] = &c
+
+	// Nullability is expressed with a type array in JSON Schema.
+	if c.Nullable {
+		c.Nullable = false
+		if c.Type != nil && !c.Type.Includes(openapi3.TypeNull) {
+			types := append(slices.Clone(c.Type.Slice()), openapi3.TypeNull)
+			c.Type = (*openapi3.Types)(&types)
+		}
+		if len(c.Enum) > 0 && !slices.Contains(c.Enum, nil) {
+			c.Enum = append(slices.Clone(c.Enum), nil)
+		}
+	}
+
+	// Exclusive bounds are numbers instead of flags in JSON Schema.
+	if c.ExclusiveMin && c.Min != nil {
+		c.Extensions = maps.Clone(c.Extensions)
+		setSchemaExtension(&c, "exclusiveMinimum", *c.Min)
+		c.ExclusiveMin, c.Min = false, nil
+	}
+	if c.ExclusiveMax && c.Max != nil {
+		c.Extensions = maps.Clone(c.Extensions)
+		setSchemaExtension(&c, "exclusiveMaximum", *c.Max)
+		c.ExclusiveMax, c.Max = false, nil
+	}
+
+	c.Items = convertSchemaRefCopyTo31(c.Items, converted)
+	c.Not = convertSchemaRefCopyTo31(c.Not, converted)
+	c.AdditionalProperties.Schema = convertSchemaRefCopyTo31(c.AdditionalProperties.Schema, converted)
+	c.OneOf = convertSchemaRefsTo31(c.OneOf, converted)
+	c.AnyOf = convertSchemaRefsTo31(c.AnyOf, converted)
+	c.AllOf = convertSchemaRefsTo31(c.AllOf, converted)
+
+	if c.Properties != nil {
+		properties := make(openapi3.Schemas, len(c.Properties))
+		for name, ref := range c.Properties {
+			properties[name] = convertSchemaRefCopyTo31(ref, converted)
+		}
+		c.Properties = properties
+	}
+
+	return &c
+}
+
+func convertSchemaRefsTo31(refs openapi3.SchemaRefs, converted map[*openapi3.Schema]*openapi3.Schema) openapi3.SchemaRefs {
+	if refs == nil {
+		return nil
+	}
+	result := make(openapi3.SchemaRefs, len(refs))
+	for i, ref := range refs {
+		result[i] = convertSchemaRefCopyTo31(ref, converted)
+	}
+	return result
+}
+
+// convertSchemaRefCopyTo31 converts a schema reference that may be shared with the registered models.
+func convertSchemaRefCopyTo31(ref *openapi3.SchemaRef, converted map[*openapi3.Schema]*openapi3.Schema) *openapi3.SchemaRef {
+	if ref == nil || ref.Value == nil {
+		return ref
+	}
+	c := *ref
+	c.Value = convertSchemaTo31(ref.Value, converted)
+	return &c
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Optional is a field that tells an absent value from a null one, e.g. in the input of a partial update.
+// It's documented as its value, which can be null, and is never required.
+// Tag the field with omitzero, so that absent values are left out when the field is encoded.
+// Example:
+//
+//	type UpdateUser struct {
+//		Name  rest.Optional[string]  `json:"name,omitzero"`
+//		Phone rest.Optional[*string] `json:"phone,omitzero"`
+//	}
+type Optional[T any] struct {
+	// Value of the field, the zero value if the field is absent or null.
+	Value T
+	// Set is whether the field is present, including when it's null.
+	Set bool
+	// Null is whether the field is null.
+	Null bool
+}
+
+// Some creates an Optional that is set to the value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// Null creates an Optional that is set to null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// Get returns the value, and whether it's set and not null.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+// IsZero is whether the value is absent, so that the omitzero option of encoding/json leaves it out.
+func (o Optional[T]) IsZero() bool {
+	return !o.Set
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	*o = Optional[T]{Set: true, Null: string(data) == "null"}
+	if o.Null {
+		return nil
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = value
+	return nil
+}
+
+func (o Optional[T]) optionalValueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// optionalValue is implemented by Optional, to get the type of its value.
+type optionalValue interface {
+	optionalValueType() reflect.Type
+}
+
+// getOptionalValueType returns the type of the value of an Optional type, and whether t is an Optional.
+func getOptionalValueType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	o, ok := reflect.Zero(t).Interface().(optionalValue)
+	if !ok {
+		return nil, false
+	}
+	return o.optionalValueType(), true
+}
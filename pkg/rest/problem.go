@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ProblemJSONContentType is the content type of problem details, see RFC 9457.
+const ProblemJSONContentType = "application/problem+json"
+
+// WithProblemDetails enables the problem details mode, see RFC 9457.
+// The ProblemDetails schema is registered, and the error responses, including the registered errors,
+// are documented as application/problem+json responses that reference it, extended with the fields of their models.
+// Adapters respond to errors with problem details.
+func WithProblemDetails() APIOpts {
+	return func(api *API) {
+		api.UseProblemDetails = true
+	}
+}
+
+// ProblemDetails is the body of an error response in the problem details mode, see RFC 9457.
+// The extension members are encoded along with the other members.
+type ProblemDetails struct {
+	// Type is a URI reference that identifies the problem type, about:blank by default.
+	Type string `json:"type"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title"`
+	// Status is the status of the response.
+	Status int `json:"status"`
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference that identifies this occurrence of the problem, e.g. the path of the request.
+	Instance string `json:"instance,omitempty"`
+	// Extensions are additional members, e.g. the fields of a registered error.
+	Extensions map[string]any `json:"-"`
+}
+
+var problemDetailsType = reflect.TypeFor[ProblemDetails]()
+
+// NewProblemDetails creates the problem details of a response with the status,
+// titled by the text of the status, e.g. Not Found.
+func NewProblemDetails(status int, detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ProblemErrorsMember is the extension member of the values that aren't JSON objects, e.g. slices,
+// see ProblemDetails.WithExtensions.
+const ProblemErrorsMember = "errors"
+
+// WithExtensions adds the JSON fields of the value, a struct or a map, to the extension members.
+// Other values, e.g. slices, are the errors member, see ProblemErrorsMember.
+// The standard members aren't overwritten.
+func (p *ProblemDetails) WithExtensions(v any) *ProblemDetails {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return p
+	}
+	var value any
+	if err = json.Unmarshal(b, &value); err != nil || value == nil {
+		return p
+	}
+	members, ok := value.(map[string]any)
+	if !ok {
+		members = map[string]any{ProblemErrorsMember: value}
+	}
+	for name, value := range members {
+		if isProblemMember(name) {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any)
+		}
+		p.Extensions[name] = value
+	}
+	return p
+}
+
+func isProblemMember(name string) bool {
+	switch name {
+	case "type", "title", "status", "detail", "instance":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	type problemDetails ProblemDetails
+	b, err := json.Marshal(problemDetails(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+	members := make(map[string]any, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		if !isProblemMember(name) {
+			members[name] = value
+		}
+	}
+	var standard map[string]any
+	if err = json.Unmarshal(b, &standard); err != nil {
+		return nil, err
+	}
+	for name, value := range standard {
+		members[name] = value
+	}
+	return json.Marshal(members)
+}
+
+func (p ProblemDetails) ApplyCustomSchema(s *openapi3.Schema) {
+	s.Description = "Problem details, see RFC 9457."
+	hasAdditionalProperties := true
+	s.AdditionalProperties = openapi3.AdditionalProperties{Has: &hasAdditionalProperties}
+}
+
+// isProblemModel reports whether the error response with the model is documented by the problem details only,
+// because it has no fields, e.g. dtos.NotFound.
+func isProblemModel(model Model) bool {
+	t := model.Type
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct && len(jsonFields(t)) == 0
+}
+
+// newProblemResponse documents a problem details response, with the members of the model, if any, as extensions.
+// The caller must hold the lock of the API.
+func (api *API) newProblemResponse(description string, model Model) (*openapi3.Response, error) {
+	name, schema, err := api.registerModel(modelFromType(problemDetailsType))
+	if err != nil {
+		return nil, err
+	}
+	schemaRef := getSchemaReferenceOrValue(name, schema)
+
+	if model.Type != nil && !isProblemModel(model) {
+		modelName, modelSchema, err := api.registerModel(model)
+		if err != nil {
+			return nil, err
+		}
+		modelRef := getSchemaReferenceOrValue(modelName, modelSchema)
+		// Values that aren't objects are the errors member.
+		if !modelSchema.Type.Is(openapi3.TypeObject) {
+			errorsSchema := openapi3.NewObjectSchema()
+			errorsSchema.Properties = openapi3.Schemas{ProblemErrorsMember: modelRef}
+			modelRef = openapi3.NewSchemaRef("", errorsSchema)
+		}
+		schemaRef = openapi3.NewSchemaRef("", &openapi3.Schema{
+			AllOf: openapi3.SchemaRefs{schemaRef, modelRef},
+		})
+	}
+
+	return openapi3.NewResponse().
+		WithDescription(description).
+		WithContent(openapi3.Content{ProblemJSONContentType: openapi3.NewMediaType().WithSchemaRef(schemaRef)}), nil
+}
@@ -2,6 +2,8 @@ package rest
 
 import (
 	"fmt"
+	"hash/fnv"
+	"net/http"
 	"reflect"
 	"slices"
 	"sort"
@@ -12,24 +14,23 @@ import (
 	"golang.org/x/exp/constraints"
 )
 
-func newSpec(name string) *openapi3.T {
+func (api *API) newSpec() *openapi3.T {
 	return &openapi3.T{
-		OpenAPI: "3.0.0",
-		Info: &openapi3.Info{
-			Title:      name,
-			Version:    "0.0.0",
-			Extensions: map[string]interface{}{},
-		},
+		OpenAPI:      string(OpenAPIVersion30),
+		Info:         api.newInfo(),
+		Servers:      api.newServers(),
+		ExternalDocs: api.ExternalDocs,
 		Components: &openapi3.Components{
-			Schemas:    make(openapi3.Schemas),
-			Extensions: map[string]interface{}{},
+			Schemas:         make(openapi3.Schemas),
+			SecuritySchemes: make(openapi3.SecuritySchemes),
+			Extensions:      map[string]interface{}{},
 		},
 		Paths:      &openapi3.Paths{},
 		Extensions: map[string]interface{}{},
 	}
 }
 
-func getSortedKeys[V any](m map[string]V) (op []string) {
+func getSortedKeys[K ~string | ~int, V any](m map[K]V) (op []K) {
 	for k := range m {
 		op = append(op, k)
 	}
@@ -60,12 +61,22 @@ func newPrimitiveSchema(paramType PrimitiveType) *openapi3.Schema {
 	}
 }
 
+// createOpenAPI builds the specification document, the caller must hold the lock of the API.
 func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
-	spec = newSpec(api.Name)
+	spec = api.newSpec()
+	// The problem details schema is registered even if no operation responds with errors.
+	if api.UseProblemDetails {
+		if _, _, err = api.registerModel(modelFromType(problemDetailsType)); err != nil {
+			return spec, err
+		}
+	}
 	// Add all the routes.
-	for pattern, methodToRoute := range api.Routes {
+	// Iterate in a stable order, so that models are registered, and named, the same way on every run.
+	for _, pattern := range getSortedKeys(api.Routes) {
+		methodToRoute := api.Routes[pattern]
 		path := &openapi3.PathItem{}
-		for method, route := range methodToRoute {
+		for _, method := range getSortedKeys(methodToRoute) {
+			route := methodToRoute[method]
 			op := &openapi3.Operation{}
 
 			// Add the Header params.
@@ -78,7 +89,8 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 					WithDescription(v.Description).
 					WithSchema(ps)
 
-				headerParams.Required = v.Required
+				hasRequiredRule := api.applyValidateTag(ps, v.StructTag)
+				headerParams.Required = v.Required || hasRequiredRule
 
 				// Apply schema customisation.
 				if v.ApplyCustomSchema != nil {
@@ -88,6 +100,26 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 				op.AddParameter(headerParams)
 			}
 
+			// Add the cookie params.
+			for _, k := range getSortedKeys(route.Params.Cookie) {
+				v := route.Params.Cookie[k]
+
+				ps := newPrimitiveSchema(v.Type).
+					WithPattern(v.Regexp)
+				cookieParam := openapi3.NewCookieParameter(k).
+					WithDescription(v.Description).
+					WithSchema(ps)
+				hasRequiredRule := api.applyValidateTag(ps, v.StructTag)
+				cookieParam.Required = v.Required || hasRequiredRule
+
+				// Apply schema customisation.
+				if v.ApplyCustomSchema != nil {
+					v.ApplyCustomSchema(cookieParam)
+				}
+
+				op.AddParameter(cookieParam)
+			}
+
 			// Add the query params.
 			for _, k := range getSortedKeys(route.Params.Query) {
 				v := route.Params.Query[k]
@@ -97,7 +129,8 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 				queryParam := openapi3.NewQueryParameter(k).
 					WithDescription(v.Description).
 					WithSchema(ps)
-				queryParam.Required = v.Required
+				hasRequiredRule := api.applyValidateTag(ps, v.StructTag)
+				queryParam.Required = v.Required || hasRequiredRule
 				queryParam.AllowEmptyValue = v.AllowEmpty
 
 				// Apply schema customisation.
@@ -117,6 +150,8 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 				pathParam := openapi3.NewPathParameter(k).
 					WithDescription(v.Description).
 					WithSchema(ps)
+				// Path parameters are always required, whatever the validator rules are.
+				api.applyValidateTag(ps, v.StructTag)
 
 				// Apply schema customisation.
 				if v.ApplyCustomSchema != nil {
@@ -128,19 +163,19 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 
 			// Handle request types.
 			if route.Models.Request.Type != nil {
-				name, schema, err := api.RegisterModel(route.Models.Request)
+				ref, err := api.registerRequestModel(route.Models.Request)
 				if err != nil {
 					return spec, err
 				}
 
-				// headerParams :=
-
-				// Value: openapi3.NewRequestBody().WithContent(openapi3.NewContentWithFormDataSchema(schema)),
-
 				types := map[string]*openapi3.MediaType{}
 				for _, v := range route.RequestContentType {
 					types[v] = &openapi3.MediaType{
-						Schema: getSchemaReferenceOrValue(name, schema),
+						Schema: ref,
+					}
+					// The media types accepted by the files of a multipart form are documented by its encoding.
+					if v == MultipartFormContentType {
+						types[v].Encoding = getFormEncoding(route.Models.Request.Type)
 					}
 				}
 
@@ -150,21 +185,25 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 			}
 
 			// Handle response types.
-			for status, model := range route.Models.Responses {
-				name, schema, err := api.RegisterModel(model)
-				if err != nil {
+			for _, status := range getSortedKeys(route.Models.Responses) {
+				if err = api.addResponse(op, status, route.Models.Responses[status]); err != nil {
+					return spec, err
+				}
+			}
+
+			// The registered errors are documented in the operations, unless they declare the same statuses.
+			if err = api.addErrorResponses(op, method); err != nil {
+				return spec, err
+			}
+
+			// The fallback responses document the statuses that are still undocumented.
+			for _, status := range getSortedKeys(route.Models.FallbackResponses) {
+				if op.Responses != nil && op.Responses.Status(status) != nil {
+					continue
+				}
+				if err = api.addResponse(op, status, route.Models.FallbackResponses[status]); err != nil {
 					return spec, err
 				}
-				resp := openapi3.NewResponse().
-					WithDescription("").
-					WithContent(
-						map[string]*openapi3.MediaType{
-							"application/json": {
-								Schema: getSchemaReferenceOrValue(name, schema),
-							},
-						},
-					)
-				op.AddResponse(status, resp)
 			}
 
 			// Handle tags.
@@ -176,26 +215,48 @@ func (api *API) createOpenAPI() (spec *openapi3.T, err error) {
 			// Handle description.
 			op.Description = route.Description
 
+			// Handle security.
+			if len(route.Security) > 0 {
+				if op.Security, err = api.newSecurityRequirements(route); err != nil {
+					return spec, err
+				}
+			}
+
 			// Register the method.
 			path.SetOperation(string(method), op)
 		}
 
-		// Populate the OpenAPI schemas from the models.
-		for name, schema := range api.models {
-			spec.Components.Schemas[name] = openapi3.NewSchemaRef("", schema)
-		}
-
 		spec.Paths.Set(string(pattern), path)
 	}
 
+	// Populate the OpenAPI schemas from the models.
+	for name, schema := range api.models {
+		spec.Components.Schemas[name] = openapi3.NewSchemaRef("", schema)
+	}
+
+	// Add the security schemes.
+	for name, scheme := range api.SecuritySchemes {
+		spec.Components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{Value: scheme}
+	}
+
 	loader := openapi3.NewLoader()
 	if err = loader.ResolveRefsIn(spec, nil); err != nil {
 		return spec, fmt.Errorf("failed to resolve, due to external references: %w", err)
 	}
-	if err = spec.Validate(loader.Context); err != nil {
+	var validationOpts []openapi3.ValidationOption
+	if api.OpenAPIVersion == OpenAPIVersion31 {
+		validationOpts = append(validationOpts, openapi3.AllowExtraSiblingFields(openAPI31Keywords...))
+	}
+	if err = spec.Validate(loader.Context, validationOpts...); err != nil {
 		return spec, fmt.Errorf("failed validation: %w", err)
 	}
 
+	// The document is validated as OpenAPI 3.0, since the openapi3 package doesn't
+	// support the JSON Schema type arrays of OpenAPI 3.1.
+	if api.OpenAPIVersion == OpenAPIVersion31 {
+		toOpenAPI31(spec)
+	}
+
 	return spec, err
 }
 
@@ -210,7 +271,10 @@ func (api *API) getModelName(t reflect.Type) string {
 	}
 	schemaName := api.normalizeTypeName(pkgPath, typeName)
 	if typeName == "" {
-		schemaName = fmt.Sprintf("AnonymousType%d", len(api.models))
+		// Name anonymous types after their structure, so that the name is stable across runs.
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(t.String()))
+		schemaName = fmt.Sprintf("AnonymousType%08x", h.Sum32())
 	}
 	return schemaName
 }
@@ -304,8 +368,21 @@ func (api *API) MustRegisterModel(model Model, opts ...ModelOpts) (name string,
 }
 
 // RegisterModel allows a model to be registered manually so that additional configuration can be applied.
-// The schema returned can be modified as required.
+// The schema returned can be modified as required, so the specification document is invalidated.
+// Modifications aren't guarded by the lock of the API, so make them before the API is used concurrently.
 func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, schema *openapi3.Schema, err error) {
+	api.mu.RLock()
+	knownTypes := api.KnownTypes
+	api.mu.RUnlock()
+	preloadComments(knownTypes, model.Type)
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.invalidateSpec()
+	return api.registerModel(model, opts...)
+}
+
+// registerModel registers the model, the caller must hold the lock of the API.
+func (api *API) registerModel(model Model, opts ...ModelOpts) (name string, schema *openapi3.Schema, err error) {
 	// Get the name.
 	t := model.Type
 	name = api.getModelName(t)
@@ -333,7 +410,7 @@ func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, sche
 	case reflect.TypeOf(uuid.UUID{}).Kind():
 		schema = openapi3.NewStringSchema()
 	case reflect.Slice, reflect.Array:
-		elementName, elementSchema, err = api.RegisterModel(modelFromType(t.Elem()))
+		elementName, elementSchema, err = api.registerModel(modelFromType(t.Elem()))
 		if err != nil {
 			return name, schema, fmt.Errorf("error getting schema of slice element %v: %w", t.Elem(), err)
 		}
@@ -349,6 +426,7 @@ func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, sche
 			}
 
 			schema.WithEnum(enumCases...)
+			schema.Description, schema.Deprecated = getTypeComment(t.PkgPath(), typeName)
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		schema = openapi3.NewIntegerSchema()
@@ -357,7 +435,14 @@ func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, sche
 	case reflect.Bool:
 		schema = openapi3.NewBoolSchema()
 	case reflect.Pointer:
-		name, schema, err = api.RegisterModel(modelFromType(t.Elem()), WithNullable())
+		name, schema, err = api.registerModel(modelFromType(t.Elem()))
+		// Referenced schemas are shared with the non-pointer type, so only inline schemas are made nullable,
+		// otherwise the component would depend on which of the types is registered first.
+		if err == nil && !shouldBeReferenced(schema) {
+			nullable := *schema
+			nullable.Nullable = true
+			schema = &nullable
+		}
 	case reflect.Map:
 		// Check that the key is a string.
 		if t.Key().Kind() != reflect.String {
@@ -365,73 +450,41 @@ func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, sche
 		}
 
 		// Get the element schema.
-		elementName, elementSchema, err = api.RegisterModel(modelFromType(t.Elem()))
+		elementName, elementSchema, err = api.registerModel(modelFromType(t.Elem()))
 		if err != nil {
 			return name, schema, fmt.Errorf("error getting schema of map value element %v: %w", t.Elem(), err)
 		}
 		schema = openapi3.NewObjectSchema().WithNullable()
 		schema.AdditionalProperties.Schema = getSchemaReferenceOrValue(elementName, elementSchema)
 	case reflect.Struct:
+		// Optional values are documented as their value, which can be null, like pointers.
+		if valueType, ok := getOptionalValueType(t); ok {
+			name, schema, err = api.registerModel(modelFromType(valueType))
+			if err == nil && !shouldBeReferenced(schema) {
+				nullable := *schema
+				nullable.Nullable = true
+				schema = &nullable
+			}
+			break
+		}
+
 		schema = openapi3.NewObjectSchema()
 
-		if t.Name() == "FileWithHeader" {
+		if t == fileType {
 			schema = openapi3.NewStringSchema().WithFormat("binary")
 			schema.Properties = make(openapi3.Schemas)
 			return
 		}
 
-		// if schema.Description, schema.Deprecated, err = api.getTypeComment(t.PkgPath(), t.Name()); err != nil {
-		// 	return name, schema, fmt.Errorf("failed to get comments for type %q: %w", name, err)
-		// }
+		schema.Description, schema.Deprecated = getTypeComment(t.PkgPath(), t.Name())
 		schema.Properties = make(openapi3.Schemas)
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if !f.IsExported() {
-				continue
-			}
-			// Get JSON fieldName.
-			jsonTags := strings.Split(f.Tag.Get("json"), ",")
-			fieldName := jsonTags[0]
-			if fieldName == "" {
-				fieldName = f.Name
-			}
-			// If the model doesn't exist.
-			_, alreadyExists := api.models[api.getModelName(f.Type)]
-			fieldSchemaName, fieldSchema, err := api.RegisterModel(modelFromType(f.Type))
-			if err != nil {
-				return name, schema, fmt.Errorf(
-					"error getting schema for type %q, field %q, failed to get schema for embedded type %q: %w",
-					t,
-					fieldName,
-					f.Type,
-					err,
-				)
-			}
-			if f.Anonymous {
-				// It's an anonymous type, no need for a reference to it,
-				// since we're copying the fields.
-				if !alreadyExists {
-					delete(api.models, fieldSchemaName)
-				}
-				// Add all embedded fields to this type.
-				for name, ref := range fieldSchema.Properties {
-					schema.Properties[name] = ref
-				}
-				schema.Required = append(schema.Required, fieldSchema.Required...)
-				continue
-			}
-			ref := getSchemaReferenceOrValue(fieldSchemaName, fieldSchema)
-			// if ref.Value != nil {
-			// if ref.Value.Description, ref.Value.Deprecated, err = api.getTypeFieldComment(t.PkgPath(), t.Name(), f.Name); err != nil {
-			// 	return name, schema, fmt.Errorf("failed to get comments for field %q in type %q: %w", fieldName, name, err)
-			// }
-			// }
-			schema.Properties[fieldName] = ref
-			isPtr := f.Type.Kind() == reflect.Pointer
-			hasOmitEmptySet := slices.Contains(jsonTags, "omitempty")
-			if isFieldRequired(isPtr, hasOmitEmptySet) {
-				schema.Required = append(schema.Required, fieldName)
-			}
+
+		// Register the schema before walking the fields, so that recursive types
+		// reference the schema that is still being built instead of recursing forever.
+		api.models[name] = schema
+		if err = api.addFieldSchemas(schema, t, jsonFields(t)); err != nil {
+			delete(api.models, name)
+			return name, schema, err
 		}
 	}
 
@@ -459,9 +512,122 @@ func (api *API) RegisterModel(model Model, opts ...ModelOpts) (name string, sche
 		return
 	}
 
+	// The struct was customised into a schema that's not referenced.
+	if t.Kind() == reflect.Struct {
+		delete(api.models, name)
+		// The fields of recursive types reference the schema, which can't be inlined into itself.
+		ref := fmt.Sprintf("#/components/schemas/%s", name)
+		recursive := referencesSchema(schema, ref)
+		for _, model := range api.models {
+			recursive = recursive || referencesSchema(model, ref)
+		}
+		if recursive {
+			return name, schema, fmt.Errorf("recursive type %q is customised into a schema that's not referenced", t)
+		}
+	}
+
 	return
 }
 
+// addFieldSchemas adds the schemas of the fields of the struct type to the properties of its schema,
+// the caller must hold the lock of the API.
+func (api *API) addFieldSchemas(schema *openapi3.Schema, t reflect.Type, fields []jsonField) error {
+	for _, jf := range fields {
+		f := jf.field
+		fieldName := jf.name
+		fieldSchemaName, fieldSchema, err := api.registerModel(modelFromType(f.Type))
+		if err != nil {
+			return fmt.Errorf(
+				"error getting schema for type %q, field %q, failed to get schema for embedded type %q: %w",
+				t,
+				fieldName,
+				f.Type,
+				err,
+			)
+		}
+		ref := getSchemaReferenceOrValue(fieldSchemaName, fieldSchema)
+		// Numbers and booleans with the string option are encoded as strings.
+		if jf.asString && !fieldSchema.Type.Is(openapi3.TypeString) {
+			ref = openapi3.NewSchemaRef("", openapi3.NewStringSchema())
+			ref.Value.Nullable = fieldSchema.Nullable
+		}
+		// Referenced schemas are shared, so the field comment and constraints only apply to inline schemas.
+		fieldConstraints := &openapi3.Schema{}
+		if ref.Value != nil {
+			description, deprecated := getTypeFieldComment(jf.parent.PkgPath(), jf.parent.Name(), f.Name)
+			if description != "" {
+				ref.Value.Description, ref.Value.Deprecated = description, deprecated
+			}
+			fieldConstraints = ref.Value
+		}
+		hasRequiredRule := api.applyValidateTag(fieldConstraints, f.Tag)
+		if IsFileType(f.Type) {
+			if err = applyFileLimits(fieldConstraints, f.Tag); err != nil {
+				return fmt.Errorf("error getting schema for type %q, field %q: %w", t, fieldName, err)
+			}
+		}
+		schema.Properties[fieldName] = ref
+		_, isOptional := getOptionalValueType(f.Type)
+		isPtr := f.Type.Kind() == reflect.Pointer || isOptional
+		if hasRequiredRule || (!jf.optional && isFieldRequired(isPtr, jf.omitEmpty)) {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+	return nil
+}
+
+// registerRequestModel registers the model of a request body and returns the schema of the body.
+// The fields of an input that are bound from parameters aren't in the body, and the fields of a form are named
+// by their form tag, so the body of such an input is documented by an inline schema,
+// and the component of its type documents it as it's encoded as JSON.
+// The caller must hold the lock of the API.
+func (api *API) registerRequestModel(model Model) (*openapi3.SchemaRef, error) {
+	t := model.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || (!IsForm(t) && len(requestBodyFields(t)) == len(jsonFields(t))) {
+		name, schema, err := api.registerModel(model)
+		if err != nil {
+			return nil, err
+		}
+		return getSchemaReferenceOrValue(name, schema), nil
+	}
+
+	schema := openapi3.NewObjectSchema()
+	schema.Description, schema.Deprecated = getTypeComment(t.PkgPath(), t.Name())
+	schema.Properties = make(openapi3.Schemas)
+	if err := api.addFieldSchemas(schema, t, requestBodyFields(t)); err != nil {
+		return nil, err
+	}
+	if api.ApplyCustomSchemaToType != nil {
+		api.ApplyCustomSchemaToType(t, schema)
+	}
+	model.ApplyCustomSchema(schema)
+	return openapi3.NewSchemaRef("", schema), nil
+}
+
+// referencesSchema reports whether the schema or its inline schemas reference the schema of the ref.
+func referencesSchema(schema *openapi3.Schema, ref string) bool {
+	if schema == nil {
+		return false
+	}
+	refs := slices.Concat(schema.AllOf, schema.AnyOf, schema.OneOf)
+	refs = append(refs, schema.Items, schema.Not, schema.AdditionalProperties.Schema)
+	for _, property := range schema.Properties {
+		refs = append(refs, property)
+	}
+	for _, schemaRef := range refs {
+		if schemaRef == nil {
+			continue
+		}
+		if schemaRef.Ref == ref || (schemaRef.Ref == "" && referencesSchema(schemaRef.Value, ref)) {
+			return true
+		}
+	}
+	return false
+}
+
 func shouldBeReferenced(schema *openapi3.Schema) bool {
 	if schema.Type.Is(openapi3.TypeObject) && schema.AdditionalProperties.Schema == nil {
 		return true
@@ -493,3 +659,36 @@ func (api *API) normalizeTypeName(pkgPath, name string) string {
 	}
 	return normalizer.Replace(pkgPath + "/" + name)
 }
+
+// addResponse documents the response of the model for the status in the operation,
+// the caller must hold the lock of the API.
+func (api *API) addResponse(op *openapi3.Operation, status int, model Model) error {
+	// Responses without a model have no content, e.g. responses to HEAD requests.
+	if model.Type == nil {
+		op.AddResponse(status, openapi3.NewResponse().WithDescription(""))
+		return nil
+	}
+	if api.UseProblemDetails && status >= http.StatusBadRequest {
+		resp, err := api.newProblemResponse("", model)
+		if err != nil {
+			return err
+		}
+		op.AddResponse(status, resp)
+		return nil
+	}
+	name, schema, err := api.registerModel(model)
+	if err != nil {
+		return err
+	}
+	resp := openapi3.NewResponse().
+		WithDescription("").
+		WithContent(
+			map[string]*openapi3.MediaType{
+				"application/json": {
+					Schema: getSchemaReferenceOrValue(name, schema),
+				},
+			},
+		)
+	op.AddResponse(status, resp)
+	return nil
+}
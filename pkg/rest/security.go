@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SecurityRequirement maps the names of security schemes to the scopes a route requires.
+// All the schemes of a requirement must be satisfied.
+type SecurityRequirement map[string][]string
+
+// APIKeyLocation is the location of an API key, e.g. a header.
+type APIKeyLocation string
+
+const (
+	APIKeyInHeader APIKeyLocation = "header"
+	APIKeyInQuery  APIKeyLocation = "query"
+	APIKeyInCookie APIKeyLocation = "cookie"
+)
+
+// NewBearerSecurityScheme creates a HTTP bearer authentication scheme.
+// The bearerFormat is a hint about the token, e.g. "JWT", and can be empty.
+func NewBearerSecurityScheme(bearerFormat string) *openapi3.SecurityScheme {
+	return openapi3.NewSecurityScheme().
+		WithType("http").
+		WithScheme("bearer").
+		WithBearerFormat(bearerFormat)
+}
+
+// NewJWTSecurityScheme creates a HTTP bearer authentication scheme for JWTs.
+func NewJWTSecurityScheme() *openapi3.SecurityScheme {
+	return NewBearerSecurityScheme("JWT")
+}
+
+// NewBasicSecurityScheme creates a HTTP basic authentication scheme.
+func NewBasicSecurityScheme() *openapi3.SecurityScheme {
+	return openapi3.NewSecurityScheme().
+		WithType("http").
+		WithScheme("basic")
+}
+
+// NewAPIKeySecurityScheme creates an API key scheme, where name is the name of the
+// header, query parameter or cookie that holds the key.
+func NewAPIKeySecurityScheme(in APIKeyLocation, name string) *openapi3.SecurityScheme {
+	return openapi3.NewSecurityScheme().
+		WithType("apiKey").
+		WithIn(string(in)).
+		WithName(name)
+}
+
+// NewOAuth2SecurityScheme creates an OAuth2 scheme with the given flows.
+// Example:
+//
+//	rest.NewOAuth2SecurityScheme(&openapi3.OAuthFlows{
+//		ClientCredentials: &openapi3.OAuthFlow{
+//			TokenURL: "https://example.com/oauth/token",
+//			Scopes:   map[string]string{"users:read": "Read users"},
+//		},
+//	})
+func NewOAuth2SecurityScheme(flows *openapi3.OAuthFlows) *openapi3.SecurityScheme {
+	return &openapi3.SecurityScheme{
+		Type:  "oauth2",
+		Flows: flows,
+	}
+}
+
+// WithSecurityScheme registers a security scheme that routes can require by name.
+func WithSecurityScheme(name string, scheme *openapi3.SecurityScheme) APIOpts {
+	return func(api *API) {
+		api.RegisterSecurityScheme(name, scheme)
+	}
+}
+
+// RegisterSecurityScheme registers a security scheme that routes can require by name.
+func (api *API) RegisterSecurityScheme(name string, scheme *openapi3.SecurityScheme) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.invalidateSpec()
+	api.SecuritySchemes[name] = scheme
+}
+
+// HasSecurity adds a requirement for a single security scheme with the given scopes.
+// Each call adds an alternative, so a route with several requirements accepts any of them.
+// Example:
+//
+//	api.Get("/user").HasSecurity("bearer").HasSecurity("oauth2", "users:read")
+func (rm *Route) HasSecurity(name string, scopes ...string) *Route {
+	return rm.HasSecurityRequirement(SecurityRequirement{name: scopes})
+}
+
+// HasSecurityRequirement adds an alternative security requirement for the route.
+func (rm *Route) HasSecurityRequirement(requirement SecurityRequirement) *Route {
+	defer rm.lock()()
+	rm.Security = append(rm.Security, requirement)
+	return rm
+}
+
+func (api *API) newSecurityRequirements(route *Route) (*openapi3.SecurityRequirements, error) {
+	requirements := openapi3.NewSecurityRequirements()
+	for _, requirement := range route.Security {
+		r := openapi3.NewSecurityRequirement()
+		for _, name := range getSortedKeys(requirement) {
+			if _, ok := api.SecuritySchemes[name]; !ok {
+				return nil, fmt.Errorf("route %s %s requires unknown security scheme %q", route.Method, route.Pattern, name)
+			}
+			r.Authenticate(name, requirement[name]...)
+		}
+		requirements.With(r)
+	}
+	return requirements, nil
+}
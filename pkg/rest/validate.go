@@ -0,0 +1,213 @@
+package rest
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ValidateTagHandler applies a validator tag to the schema of a field,
+// where param is the parameter of the tag, e.g. "3" for min=3, or an empty string.
+type ValidateTagHandler func(s *openapi3.Schema, param string)
+
+// WithValidateTagName sets the name of the struct tag used by the validator, "validate" by default.
+func WithValidateTagName(name string) APIOpts {
+	return func(api *API) {
+		api.ValidateTagName = name
+	}
+}
+
+// WithValidateTag registers a handler for a validator tag, e.g. a custom validation,
+// replacing the default handler of the tag if there is one.
+// Example:
+//
+//	rest.WithValidateTag("slug", func(s *openapi3.Schema, _ string) {
+//		s.Pattern = "^[a-z0-9-]+$"
+//	})
+func WithValidateTag(tag string, handler ValidateTagHandler) APIOpts {
+	return func(api *API) {
+		api.ValidateTags[tag] = handler
+	}
+}
+
+func newDefaultValidateTags() map[string]ValidateTagHandler {
+	return map[string]ValidateTagHandler{
+		"min":      applyMin,
+		"gte":      applyMin,
+		"max":      applyMax,
+		"lte":      applyMax,
+		"len":      applyLen,
+		"gt":       applyGt,
+		"lt":       applyLt,
+		"oneof":    applyOneOf,
+		"email":    withFormat("email"),
+		"url":      withFormat("uri"),
+		"uri":      withFormat("uri"),
+		"uuid":     withFormat("uuid"),
+		"uuid4":    withFormat("uuid"),
+		"datetime": applyDatetime,
+		"ipv4":     withFormat("ipv4"),
+		"ipv6":     withFormat("ipv6"),
+		"hostname": withFormat("hostname"),
+		// The patterns are the regular expressions of the validator.
+		"alpha":       withPattern(`^[a-zA-Z]+$`),
+		"alphanum":    withPattern(`^[a-zA-Z0-9]+$`),
+		"numeric":     withPattern(`^[-+]?[0-9]+(?:\.[0-9]+)?$`),
+		"number":      withPattern(`^[0-9]+$`),
+		"hexadecimal": withPattern(`^(0[xX])?[0-9a-fA-F]+$`),
+		"hexcolor":    withPattern(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`),
+		"e164":        withPattern(`^\+[1-9]?[0-9]{7,14}$`),
+	}
+}
+
+// applyValidateTag applies the validator rules of the struct tag to the schema,
+// and returns whether the field is required.
+// Rules after dive apply to the elements of a slice or map, so they are ignored.
+func (api *API) applyValidateTag(s *openapi3.Schema, tag reflect.StructTag) (required bool) {
+	rules := tag.Get(api.ValidateTagName)
+	if rules == "" {
+		return false
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			break
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if handler, ok := api.ValidateTags[name]; ok {
+			handler(s, param)
+		}
+	}
+	return required
+}
+
+func withFormat(format string) ValidateTagHandler {
+	return func(s *openapi3.Schema, _ string) {
+		s.Format = format
+	}
+}
+
+// withPattern documents the rule by the pattern of the strings it accepts, it doesn't apply to other types,
+// e.g. numbers are always numeric.
+func withPattern(pattern string) ValidateTagHandler {
+	return func(s *openapi3.Schema, _ string) {
+		if s.Type.Is(openapi3.TypeString) {
+			s.Pattern = pattern
+		}
+	}
+}
+
+// applyDatetime documents the layout of the datetime rule, e.g. datetime=2006-01-02, by the format it matches,
+// date or date-time. Other layouts have no format.
+func applyDatetime(s *openapi3.Schema, param string) {
+	switch param {
+	case time.DateOnly:
+		s.Format = "date"
+	case time.RFC3339, time.RFC3339Nano:
+		s.Format = "date-time"
+	}
+}
+
+func applyMin(s *openapi3.Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch {
+	case s.Type.Is(openapi3.TypeString):
+		s.MinLength = uint64(n)
+	case s.Type.Is(openapi3.TypeArray):
+		s.MinItems = uint64(n)
+	case s.Type.Is(openapi3.TypeObject):
+		s.MinProps = uint64(n)
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		s.Min = &n
+	}
+}
+
+func applyMax(s *openapi3.Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch {
+	case s.Type.Is(openapi3.TypeString):
+		s.MaxLength = openapi3.Uint64Ptr(uint64(n))
+	case s.Type.Is(openapi3.TypeArray):
+		s.MaxItems = openapi3.Uint64Ptr(uint64(n))
+	case s.Type.Is(openapi3.TypeObject):
+		s.MaxProps = openapi3.Uint64Ptr(uint64(n))
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		s.Max = &n
+	}
+}
+
+func applyLen(s *openapi3.Schema, param string) {
+	applyMin(s, param)
+	applyMax(s, param)
+}
+
+func applyGt(s *openapi3.Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	if s.Type.Is(openapi3.TypeInteger) || s.Type.Is(openapi3.TypeNumber) {
+		s.Min = &n
+		s.ExclusiveMin = true
+		return
+	}
+	// Lengths are integers, so the exclusive bound is the next integer.
+	applyMin(s, strconv.FormatFloat(math.Floor(n)+1, 'f', -1, 64))
+}
+
+func applyLt(s *openapi3.Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	if s.Type.Is(openapi3.TypeInteger) || s.Type.Is(openapi3.TypeNumber) {
+		s.Max = &n
+		s.ExclusiveMax = true
+		return
+	}
+	if n < 1 {
+		return
+	}
+	applyMax(s, strconv.FormatFloat(math.Ceil(n)-1, 'f', -1, 64))
+}
+
+// oneOfValues matches the values of the oneof tag, which can be quoted to contain spaces.
+var oneOfValues = regexp.MustCompile(`'[^']*'|\S+`)
+
+func applyOneOf(s *openapi3.Schema, param string) {
+	var enum []any
+	for _, v := range oneOfValues.FindAllString(param, -1) {
+		v = strings.Trim(v, "'")
+		switch {
+		case s.Type.Is(openapi3.TypeInteger):
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return
+			}
+			enum = append(enum, n)
+		case s.Type.Is(openapi3.TypeNumber):
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return
+			}
+			enum = append(enum, n)
+		default:
+			enum = append(enum, v)
+		}
+	}
+	s.Enum = enum
+}
@@ -0,0 +1,89 @@
+package swaglay
+
+import (
+	"net/http"
+)
+
+// SuccessStatus is the status of the successful responses of the operations of the method,
+// that registerHandler documents and the adapters respond with:
+// 201 for POST, 204 for OPTIONS and for DELETE without output, and 200 otherwise.
+func SuccessStatus(method string, hasOutput bool) int {
+	switch method {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodOptions:
+		return http.StatusNoContent
+	case http.MethodDelete:
+		if hasOutput {
+			return http.StatusOK
+		}
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
+
+// HasResponseBody reports whether the responses with the status have a body,
+// 1xx, 204 and 304 responses don't.
+func HasResponseBody(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// Resulter is the output of a handler that sets the status and the headers of the response, see Result.
+type Resulter interface {
+	Result() (status int, header http.Header, body any)
+}
+
+// Result is the output of a handler that sets the status and the headers of the response.
+// It's documented as its body.
+// Example:
+//
+//	swaglay_fiber.PostIO("users", "/users", func(i *CreateUser, ctx fiber.Ctx) (swaglay.Result[*User], error) {
+//		user := users.Create(i)
+//		return swaglay.NewResult(http.StatusCreated, user).WithHeader("Location", "/users/"+user.ID), nil
+//	}, "Create user")
+type Result[T any] struct {
+	// Status of the response, the success status of the operation if zero, see SuccessStatus.
+	Status int
+	// Header is added to the headers of the response.
+	Header http.Header
+	// Body of the response, encoded as JSON, unless the status has no body, e.g. 204.
+	Body T
+}
+
+var _ Resulter = Result[any]{}
+
+// NewResult creates a Result with the status and the body.
+func NewResult[T any](status int, body T) Result[T] {
+	return Result[T]{Status: status, Body: body}
+}
+
+// NoContent creates a Result without body, with the 204 status.
+func NoContent[T any]() Result[T] {
+	return Result[T]{Status: http.StatusNoContent}
+}
+
+// WithHeader adds the header to the response.
+func (r Result[T]) WithHeader(key, value string) Result[T] {
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Add(key, value)
+	r.Header = header
+	return r
+}
+
+func (r Result[T]) Result() (status int, header http.Header, body any) {
+	return r.Status, r.Header, r.Body
+}
+
+// getResponseBody returns the value the response body is documented from,
+// the body of a Result, or the output itself.
+func getResponseBody(out any) any {
+	if result, ok := out.(Resulter); ok {
+		_, _, body := result.Result()
+		return body
+	}
+	return out
+}
@@ -12,30 +12,51 @@ import (
 	"strings"
 )
 
+// Api is the API that the helpers without an explicit API register handlers in.
 var Api *rest.API
 
-func SetupApi(name string) {
-	Api = rest.NewAPI(name)
+// SetupApi creates the global Api.
+func SetupApi(name string, opts ...rest.APIOpts) {
+	Api = NewApi(name, opts...)
+}
+
+// NewApi creates an API with the models of the default responses registered,
+// e.g. to publish several specifications, such as a public and an admin API, from one binary.
+// Register handlers in it with the helpers that take an API, such as RegisterHandlerIOFor.
+func NewApi(name string, opts ...rest.APIOpts) *rest.API {
+	api := rest.NewAPI(name, opts...)
 
-	_, _, err := Api.RegisterModel(rest.ModelOf[dtos.NotFound](), rest.WithDescription("Resource not found"))
+	_, _, err := api.RegisterModel(rest.ModelOf[dtos.NotFound](), rest.WithDescription("Resource not found"))
 	if err != nil {
 		panic(err)
 	}
-	_, _, err = Api.RegisterModel(rest.ModelOf[dtos.BadRequest](), rest.WithDescription("Invalid input"))
+	_, _, err = api.RegisterModel(rest.ModelOf[dtos.BadRequest](), rest.WithDescription("Invalid input"))
 	if err != nil {
 		panic(err)
 	}
 
 	description := "Unprocessable entity"
-	_, _, err = Api.RegisterModel(rest.ModelOf[dtos.UnprocessableEntity](), rest.WithDescription(description))
+	_, _, err = api.RegisterModel(rest.ModelOf[dtos.UnprocessableEntity](), rest.WithDescription(description))
 	if err != nil {
 		panic(err)
 	}
+
+	return api
 }
 
-func register(values ...any) {
+func register(api *rest.API, values ...any) {
+	assertApiIsSetup(api)
 	for _, value := range values {
-		Api.MustRegisterModel(rest.ModelOfReflect(value))
+		// Composite inputs are documented by the model of their body, and results by their body.
+		value, _ = getRequestBody(value)
+		value = getResponseBody(value)
+		if fields := GetResponseFields(reflect.TypeOf(value)); fields != nil {
+			for _, field := range fields {
+				api.MustRegisterModel(rest.ModelOfReflect(reflect.Zero(field.Type).Interface()))
+			}
+			continue
+		}
+		api.MustRegisterModel(rest.ModelOfReflect(value))
 	}
 }
 
@@ -53,51 +74,92 @@ func extractReplacements(s string) []string {
 	return results
 }
 
-func assertApiIsSetup() {
-	if Api == nil {
+func assertApiIsSetup(api *rest.API) {
+	if api == nil {
 		panic("Api is not setup")
 	}
 }
 
-func registerHandler(resourceName, url, method, name string, in any, out any) {
-	assertApiIsSetup()
+func registerHandler(api *rest.API, resourceName, url, method, name string, in any, out any) {
+	assertApiIsSetup(api)
 	const separator = "-"
 	name = regexp.MustCompile(`\s+`).ReplaceAllString(name, separator)            // Spaces to "_"
 	name = regexp.MustCompile(`[^a-zA-Z0-9_]+`).ReplaceAllString(name, separator) // Special chars to "_"
 	name = strings.ToLower(name)
 
-	operationByMethod := map[string]func(pattern string) (r *rest.Route){
-		http.MethodGet:     Api.Get,
-		http.MethodPost:    Api.Post,
-		http.MethodPut:     Api.Put,
-		http.MethodPatch:   Api.Patch,
-		http.MethodDelete:  Api.Delete,
-		http.MethodHead:    Api.Head,
-		http.MethodConnect: Api.Connect,
-		http.MethodOptions: Api.Options,
-		http.MethodTrace:   Api.Trace,
-	}
-
 	if name == "" {
 		name = resourceName + "_" + method + "_" + url
 	}
 
-	operation := operationByMethod[method](url).
+	// The route is configured before it's added, so that concurrent Spec calls don't see it partially configured.
+	operation := rest.NewRoute(method, url).
 		HasTags([]string{resourceName}).
 		HasOperationID(name)
 
+	// A composite input binds the request body to one of its fields, and the others to parameters.
+	body, composite := getRequestBody(in)
+	out = getResponseBody(out)
+	// A Responses output documents its responses instead of the success response of the method.
+	responseFields := GetResponseFields(reflect.TypeOf(out))
+	if responseFields != nil {
+		out = nil
+	}
+	status := SuccessStatus(method, out != nil)
+
+	// Path, query, header and cookie parameters that the input binds to its fields are documented from the fields.
+	pathParameters := make(map[string]rest.PathParam)
+	if reflect.TypeOf(in) != nil && reflect.TypeOf(in).Kind() == reflect.Struct {
+		parameters, err := swaglay_qf.NewPathParametersFromValue(in)
+		if err != nil {
+			panic(err)
+		}
+		for _, parameter := range parameters {
+			pathParameters[parameter.ParamName] = parameter.ParamData
+		}
+
+		queryParameters, err := swaglay_qf.NewTaggedQueryParametersFromValue(in)
+		if err != nil {
+			panic(err)
+		}
+		for _, parameter := range queryParameters {
+			operation.HasQueryParameter(parameter.ParamName, parameter.ParamData)
+		}
+
+		headerParameters, err := swaglay_qf.NewHeaderParametersFromValue(in)
+		if err != nil {
+			panic(err)
+		}
+		for _, parameter := range headerParameters {
+			operation.HasHeaderParameter(parameter.ParamName, parameter.ParamData)
+		}
+
+		cookieParameters, err := swaglay_qf.NewCookieParametersFromValue(in)
+		if err != nil {
+			panic(err)
+		}
+		for _, parameter := range cookieParameters {
+			operation.HasCookieParameter(parameter.ParamName, parameter.ParamData)
+		}
+	}
+
 	// extract slice of {...} from url
 	replacements := extractReplacements(url)
 	for _, replacement := range replacements {
-		operation.HasPathParameter(
-			replacement, rest.PathParam{
-				Description: "This is a replacement for " + replacement,
-				Type:        rest.PrimitiveTypeString,
-			},
-		)
+		parameter, ok := pathParameters[replacement]
+		if !ok {
+			parameter.Type = rest.PrimitiveTypeString
+		}
+		if parameter.Description == "" {
+			parameter.Description = "This is a replacement for " + replacement
+		}
+		operation.HasPathParameter(replacement, parameter)
+		delete(pathParameters, replacement)
+	}
+	for name := range pathParameters {
+		panic(fmt.Sprintf("path parameter %q of %T is not in the url %q", name, in, url))
 	}
 
-	isCollection := method == "GET" && !strings.Contains(url, "{id}")
+	isCollection := (method == http.MethodGet || method == http.MethodHead) && !strings.Contains(url, "{id}")
 
 	pathDescription := getPathDescription(resourceName, method, isCollection)
 
@@ -105,21 +167,20 @@ func registerHandler(resourceName, url, method, name string, in any, out any) {
 
 	switch method {
 	case http.MethodGet:
+		// Responses without output have no content.
 		var retrievedModel rest.Model
 
 		if out != nil {
 			s := &openapi3.Schema{Description: fmt.Sprintf("%s resource", resourceName)}
 			retrievedModel = rest.ModelOfReflect(out)
 			retrievedModel.ApplyCustomSchema(s)
-		} else {
-			retrievedModel = rest.ModelOf[dtos.OK]()
 		}
 
 		operation.
-			HasResponseModel(http.StatusOK, retrievedModel).
-			HasResponseModel(http.StatusNotFound, rest.ModelOf[dtos.NotFound]())
+			HasResponseModel(status, retrievedModel).
+			HasFallbackResponseModel(http.StatusNotFound, rest.ModelOf[dtos.NotFound]())
 
-		if reflect.TypeOf(in) != nil {
+		if reflect.TypeOf(in) != nil && !composite {
 			parameters, err := swaglay_qf.NewQueryParametersFromValue(in)
 			if err != nil {
 				panic(err)
@@ -129,60 +190,109 @@ func registerHandler(resourceName, url, method, name string, in any, out any) {
 			}
 		}
 	case http.MethodPost:
-		s := &openapi3.Schema{Description: fmt.Sprintf("%s resource created", resourceName)}
+		documentBodyRoute(operation, resourceName+" resource created", status, out, body)
+	case http.MethodPut, http.MethodPatch:
+		documentBodyRoute(operation, resourceName+" resource updated", status, out, body)
+	case http.MethodHead:
+		// Responses to HEAD requests have no body, so they're documented without a model.
+		operation.
+			HasResponseModel(status, rest.Model{}).
+			HasFallbackResponseModel(http.StatusNotFound, rest.Model{})
 
-		var createdModel rest.Model
+		if reflect.TypeOf(in) != nil && !composite {
+			parameters, err := swaglay_qf.NewQueryParametersFromValue(in)
+			if err != nil {
+				panic(err)
+			}
+			for _, parameter := range parameters {
+				operation.HasQueryParameter(parameter.ParamName, parameter.ParamData)
+			}
+		}
+	case http.MethodOptions:
+		operation.HasResponseModel(status, rest.Model{})
+	case http.MethodDelete:
+		// Responses without output have no content.
+		var deleteModel rest.Model
 
 		if out != nil {
-			createdModel = rest.ModelOfReflect(out)
-		} else {
-			createdModel = rest.ModelOf[dtos.Created]()
+			s := &openapi3.Schema{Description: fmt.Sprintf("%s resource deleted", resourceName)}
+			deleteModel = rest.ModelOfReflect(out)
+			deleteModel.ApplyCustomSchema(s)
 		}
 
-		createdModel.ApplyCustomSchema(s)
+		operation.HasResponseModel(status, deleteModel)
 
-		operation.
-			HasResponseModel(http.StatusCreated, createdModel).
-			HasResponseModel(http.StatusBadRequest, rest.ModelOf[dtos.BadRequest]()).
-			HasResponseModel(http.StatusUnprocessableEntity, rest.ModelOf[dtos.UnprocessableEntity]()).
-			HasRequestModel(rest.ModelOfReflect(in))
-	case http.MethodPut:
-		s := &openapi3.Schema{Description: fmt.Sprintf("%s resource updated", resourceName)}
+		// The input is bound from the query string, as for GET.
+		if reflect.TypeOf(in) != nil && !composite {
+			parameters, err := swaglay_qf.NewQueryParametersFromValue(in)
+			if err != nil {
+				panic(err)
+			}
+			for _, parameter := range parameters {
+				operation.HasQueryParameter(parameter.ParamName, parameter.ParamData)
+			}
+		}
+	default:
+		panic("unsupported method: " + method)
+	}
 
-		var updatedModel rest.Model
+	// The body of a composite input is documented whatever the method is.
+	if composite {
+		operation.HasRequestModel(rest.ModelOfReflect(body))
+	}
 
-		if out != nil {
-			updatedModel = rest.ModelOfReflect(out)
-		} else {
-			updatedModel = rest.ModelOf[dtos.OK]()
+	if responseFields != nil {
+		delete(operation.Models.Responses, status)
+		for _, field := range responseFields {
+			var model rest.Model
+			if HasResponseBody(field.Status) {
+				model = rest.ModelOfReflect(reflect.Zero(field.Type).Interface())
+			}
+			operation.HasResponseModel(field.Status, model)
 		}
+	}
 
-		updatedModel.ApplyCustomSchema(s)
+	api.AddRoute(*operation)
+}
 
-		operation.
-			HasResponseModel(http.StatusOK, updatedModel).
-			HasResponseModel(http.StatusBadRequest, rest.ModelOf[dtos.BadRequest]()).
-			HasResponseModel(http.StatusUnprocessableEntity, rest.ModelOf[dtos.UnprocessableEntity]()).
-			HasRequestModel(rest.ModelOfReflect(in))
-	case http.MethodDelete:
-		s := &openapi3.Schema{Description: fmt.Sprintf("%s resource deleted", resourceName)}
+// documentBodyRoute documents the request body and the responses of a route whose input is the request body,
+// the success response is described by the description, and invalid bodies are answered with 400 or 422.
+func documentBodyRoute(operation *rest.Route, description string, status int, out, body any) {
+	// Responses without output have no content.
+	var model rest.Model
 
-		var deleteModel rest.Model
+	if out != nil {
+		s := &openapi3.Schema{Description: description}
+		model = rest.ModelOfReflect(out)
+		model.ApplyCustomSchema(s)
+	}
 
-		if out != nil {
-			deleteModel = rest.ModelOfReflect(out)
-		} else {
-			deleteModel = rest.ModelOf[dtos.OK]()
-		}
+	operation.
+		HasResponseModel(status, model).
+		HasFallbackResponseModel(http.StatusBadRequest, rest.ModelOf[dtos.BadRequest]()).
+		HasFallbackResponseModel(http.StatusUnprocessableEntity, rest.ModelOf[dtos.UnprocessableEntity]()).
+		HasRequestModel(rest.ModelOfReflect(body))
+}
 
-		deleteModel.ApplyCustomSchema(s)
+// getRequestBody returns the value the request body is documented from, a zero value of the field tagged body
+// of a composite input, and whether the input is composite. Other inputs are the request body themselves.
+func getRequestBody(in any) (any, bool) {
+	t := reflect.TypeOf(in)
+	if t == nil || t.Kind() != reflect.Struct {
+		return in, false
+	}
 
-		operation.
-			HasResponseModel(http.StatusNoContent, deleteModel).
-			HasRequestModel(rest.ModelOfReflect(in))
-	default:
-		panic("unsupported method: " + method)
+	field, ok := swaglay_qf.GetBodyField(t)
+	if !ok {
+		return in, false
+	}
+
+	bodyType := field.Field.Type
+	if bodyType.Kind() == reflect.Pointer {
+		bodyType = bodyType.Elem()
 	}
+
+	return reflect.Zero(bodyType).Interface(), true
 }
 
 func getPathDescription(resourceShortName, method string, isCollection bool) string {
@@ -197,6 +307,14 @@ func getPathDescription(resourceShortName, method string, isCollection bool) str
 		}
 	case "POST":
 		pathSummary = "Creates a %s resource."
+	case "HEAD":
+		if isCollection {
+			pathSummary = "Retrieves the headers of the collection of %s resources."
+		} else {
+			pathSummary = "Retrieves the headers of a %s resource."
+		}
+	case "OPTIONS":
+		pathSummary = "Describes the methods allowed for the %s resource."
 	case "PATCH":
 		pathSummary = "Updates the %s resource."
 	case "PUT":
@@ -211,35 +329,65 @@ func getPathDescription(resourceShortName, method string, isCollection bool) str
 }
 
 func RegisterHandlerIO[In any, Out any](resourceName string, url string, method string, name string) {
-	var in In
-	var out Out
-	register(in, out)
-	registerHandler(resourceName, url, method, name, in, out)
+	RegisterHandlerIOFor[In, Out](Api, resourceName, url, method, name)
 }
 
 func RegisterHandlerI[In any](resourceName string, url string, method string, name string) {
-	var in In
-	register(in)
-	registerHandler(resourceName, url, method, name, in, nil)
+	RegisterHandlerIFor[In](Api, resourceName, url, method, name)
 }
 
 func RegisterHandlerO[Out any](resourceName string, url string, method string, name string) {
-	var out Out
-	RegisterHandlerVarO(resourceName, url, method, name, out)
+	RegisterHandlerOFor[Out](Api, resourceName, url, method, name)
 }
 
 func RegisterHandlerIVarO[In any, Out any](resourceName string, url string, method string, name string, out Out) {
-	var in In
-	register(in)
-	register(out)
-	registerHandler(resourceName, url, method, name, in, out)
+	RegisterHandlerIVarOFor[In](Api, resourceName, url, method, name, out)
 }
 
 func RegisterHandlerVarO[Out any](resourceName string, url string, method string, name string, out Out) {
-	register(out)
-	registerHandler(resourceName, url, method, name, nil, out)
+	RegisterHandlerVarOFor(Api, resourceName, url, method, name, out)
 }
 
 func RegisterHandler(resourceName string, url string, method string, name string) {
-	registerHandler(resourceName, url, method, name, nil, nil)
+	RegisterHandlerFor(Api, resourceName, url, method, name)
+}
+
+// RegisterHandlerIOFor is like RegisterHandlerIO, but registers the handler in the given API.
+func RegisterHandlerIOFor[In any, Out any](api *rest.API, resourceName string, url string, method string, name string) {
+	var in In
+	var out Out
+	register(api, in, out)
+	registerHandler(api, resourceName, url, method, name, in, out)
+}
+
+// RegisterHandlerIFor is like RegisterHandlerI, but registers the handler in the given API.
+func RegisterHandlerIFor[In any](api *rest.API, resourceName string, url string, method string, name string) {
+	var in In
+	register(api, in)
+	registerHandler(api, resourceName, url, method, name, in, nil)
+}
+
+// RegisterHandlerOFor is like RegisterHandlerO, but registers the handler in the given API.
+func RegisterHandlerOFor[Out any](api *rest.API, resourceName string, url string, method string, name string) {
+	var out Out
+	RegisterHandlerVarOFor(api, resourceName, url, method, name, out)
+}
+
+// RegisterHandlerIVarOFor is like RegisterHandlerIVarO, but registers the handler in the given API.
+func RegisterHandlerIVarOFor[In any, Out any](api *rest.API, resourceName string, url string, method string, name string, out Out) {
+	var in In
+	register(api, in)
+	register(api, out)
+	registerHandler(api, resourceName, url, method, name, in, out)
+}
+
+// RegisterHandlerVarOFor is like RegisterHandlerVarO, but registers the handler in the given API.
+func RegisterHandlerVarOFor[Out any](api *rest.API, resourceName string, url string, method string, name string, out Out) {
+	register(api, out)
+	registerHandler(api, resourceName, url, method, name, nil, out)
+}
+
+// RegisterHandlerFor is like RegisterHandler, but registers the handler in the given API.
+func RegisterHandlerFor(api *rest.API, resourceName string, url string, method string, name string) {
+	registerHandler(api, resourceName, url, method, name, nil, nil)
 }
@@ -0,0 +1,94 @@
+package swaglay_golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that rewrites the golden files instead of comparing them, e.g.
+//
+//	SWAGLAY_UPDATE_GOLDEN=1 go test ./...
+const UpdateEnv = "SWAGLAY_UPDATE_GOLDEN"
+
+// AssertSpec compares the specification of the API with the golden file at path.
+// The golden file is YAML if path ends with .yaml or .yml, and indented JSON otherwise.
+// When UpdateEnv is set, the golden file is written instead.
+func AssertSpec(t testing.TB, api *rest.API, path string) {
+	t.Helper()
+
+	actual, err := render(api, path)
+	if err != nil {
+		t.Fatalf("failed to render spec: %s", err)
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %s", err)
+		}
+		if err = os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %s", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run the test with %s=1 to create it: %s", UpdateEnv, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		line, expectedLine, actualLine := firstDifference(string(expected), string(actual))
+		t.Errorf(
+			"spec differs from golden file %s at line %d:\n  expected: %s\n  actual:   %s\nrun the test with %s=1 to update it",
+			path,
+			line,
+			expectedLine,
+			actualLine,
+			UpdateEnv,
+		)
+	}
+}
+
+func render(api *rest.API, path string) ([]byte, error) {
+	spec, err := api.Spec()
+	if err != nil {
+		return nil, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return yaml.Marshal(spec)
+	default:
+		b, err := json.MarshalIndent(spec, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+}
+
+func firstDifference(expected, actual string) (line int, expectedLine, actualLine string) {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	for i := 0; i < max(len(expectedLines), len(actualLines)); i++ {
+		expectedLine, actualLine = "<EOF>", "<EOF>"
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+		if expectedLine != actualLine {
+			return i + 1, expectedLine, actualLine
+		}
+	}
+
+	return 0, "", ""
+}
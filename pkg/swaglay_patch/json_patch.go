@@ -0,0 +1,360 @@
+package swaglay_patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Operations of JSON Patch documents.
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902) document.
+type PatchOperation struct {
+	// Op is the operation to perform, one of the PatchOp constants.
+	Op string `json:"op"`
+	// Path is the JSON Pointer to the location of the operation.
+	Path string `json:"path"`
+	// From is the JSON Pointer to the location that the move and copy operations take the value from.
+	From string `json:"from,omitempty"`
+	// Value of the add, replace and test operations.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyCustomSchema documents the operations, and the value as any JSON value, instead of the bytes it's kept in.
+func (PatchOperation) ApplyCustomSchema(s *openapi3.Schema) {
+	s.Properties["op"].Value.WithEnum(PatchOpAdd, PatchOpRemove, PatchOpReplace, PatchOpMove, PatchOpCopy, PatchOpTest)
+	s.Properties["value"] = openapi3.NewSchemaRef("", &openapi3.Schema{
+		Description: "Value of the add, replace and test operations.",
+	})
+}
+
+// Patch is a JSON Patch (RFC 6902) document, use it as the input of a handler to receive a JSON Patch,
+// that is documented as application/json-patch+json too.
+// Example:
+//
+//	swaglay_fiber.PatchIO("users", "/users/{id}", func(patch *swaglay_patch.Patch, ctx fiber.Ctx) (*User, error) {
+//		user := users.Find(ctx.Params("id"))
+//		return user, patch.Apply(user)
+//	}, "Patch user")
+type Patch []PatchOperation
+
+func (Patch) RequestContentTypes() []string {
+	return []string{JSONPatchContentType}
+}
+
+// Apply applies the operations of the patch to the target, a pointer to a DTO, through its JSON encoding.
+// The target is only changed if all the operations succeed. The errors wrap ErrPatchFailed.
+func (p Patch) Apply(target any) error {
+	if err := p.apply(target); err != nil {
+		return fmt.Errorf("%w: %w", ErrPatchFailed, err)
+	}
+	return nil
+}
+
+func (p Patch) apply(target any) error {
+	doc, err := decodeTarget(target)
+	if err != nil {
+		return err
+	}
+
+	for i, operation := range p {
+		if doc, err = operation.apply(doc); err != nil {
+			return fmt.Errorf("operation %d, %s %q: %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return encodeTarget(doc, target)
+}
+
+func (o PatchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		switch o.Op {
+		case PatchOpAdd:
+			return add(doc, path, value)
+		case PatchOpReplace:
+			return replace(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, errors.New("test failed, the values are not equal")
+			}
+			return doc, nil
+		}
+	case PatchOpRemove:
+		return remove(doc, path)
+	case PatchOpMove, PatchOpCopy:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if o.Op == PatchOpCopy {
+			return add(doc, path, deepCopy(value))
+		}
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, errors.New("a value can't be moved into one of its children")
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", o.Op)
+	}
+}
+
+func (o PatchOperation) value() (any, error) {
+	if o.Value == nil {
+		return nil, errors.New("missing value")
+	}
+	return decode(o.Value)
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex parses the index of an array element, which must be less than size.
+func arrayIndex(token string, size int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= size {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("%q is not in an object or an array", token)
+		}
+	}
+	return doc, nil
+}
+
+// update replaces the parent of the location of the path with the result of fn,
+// and returns the updated document.
+func update(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	token := path[0]
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		updated, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = updated
+		return container, nil
+	case []any:
+		i, err := arrayIndex(token, len(container))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(container[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[i] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("%q is not in an object or an array", token)
+	}
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			i, err := arrayIndex(token, len(container)+1)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(container, i, value), nil
+		default:
+			return nil, fmt.Errorf("%q is not in an object or an array", token)
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the whole document can't be removed")
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			delete(container, token)
+			return container, nil
+		case []any:
+			i, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			return slices.Delete(container, i, i+1), nil
+		default:
+			return nil, fmt.Errorf("%q is not in an object or an array", token)
+		}
+	})
+}
+
+func replace(doc any, path []string, value any) (any, error) {
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			// The index is checked by get.
+			i, _ := arrayIndex(token, len(container))
+			container[i] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%q is not in an object or an array", token)
+		}
+	})
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, member := range v {
+			c[name] = deepCopy(member)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, element := range v {
+			c[i] = deepCopy(element)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// equal compares JSON values as the test operation does, numbers are equal if their values are.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, member := range x {
+			other, ok := y[name]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		xr, xok := new(big.Rat).SetString(x.String())
+		yr, yok := new(big.Rat).SetString(y.String())
+		return xok && yok && xr.Cmp(yr) == 0
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,130 @@
+package swaglay_patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// ErrPatchFailed is wrapped by the errors of Merge and Patch.Apply, so that they can be mapped to a response,
+// e.g. api.RegisterError(swaglay_patch.ErrPatchFailed, rest.ErrorResponse{Status: http.StatusUnprocessableEntity}).
+var ErrPatchFailed = errors.New("patch failed")
+
+// MergePatch marks an input DTO as a JSON Merge Patch (RFC 7386) document, so that the request body
+// is documented as application/merge-patch+json too. Embed it in the DTO, and declare the fields
+// with rest.Optional to tell an absent field from a field set to null.
+// Example:
+//
+//	type UpdateUser struct {
+//		swaglay_patch.MergePatch
+//		Name  rest.Optional[string]  `json:"name,omitzero"`
+//		Phone rest.Optional[*string] `json:"phone,omitzero"`
+//	}
+type MergePatch struct{}
+
+func (MergePatch) RequestContentTypes() []string {
+	return []string{MergePatchContentType}
+}
+
+// Merge applies a JSON Merge Patch (RFC 7386) to the target, a pointer to a DTO, through its JSON encoding.
+// The patch is encoded with encoding/json, so it's either a json.RawMessage of the document,
+// or a DTO with rest.Optional fields tagged omitzero, which leaves out the absent fields.
+// Members set to null are removed, so the fields of the target they're decoded into are reset.
+// The errors wrap ErrPatchFailed.
+func Merge(target any, patch any) error {
+	if err := merge(target, patch); err != nil {
+		return fmt.Errorf("%w: %w", ErrPatchFailed, err)
+	}
+	return nil
+}
+
+func merge(target any, patch any) error {
+	doc, err := decodeTarget(target)
+	if err != nil {
+		return err
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	patchDoc, err := decode(patchBytes)
+	if err != nil {
+		return err
+	}
+
+	return encodeTarget(mergeDocument(doc, patchDoc), target)
+}
+
+func mergeDocument(doc, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	docObject, ok := doc.(map[string]any)
+	if !ok {
+		docObject = make(map[string]any)
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(docObject, name)
+		} else {
+			docObject[name] = mergeDocument(docObject[name], value)
+		}
+	}
+
+	return docObject
+}
+
+// decodeTarget decodes the JSON encoding of the target into a document of maps, slices and values.
+func decodeTarget(target any) (any, error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, errors.New("target must be a non-nil pointer")
+	}
+
+	b, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(b)
+}
+
+// encodeTarget decodes the document into a new value of the target, that replaces the target if it succeeds,
+// so that the fields removed from the document are reset.
+func encodeTarget(doc any, target any) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.New(reflect.TypeOf(target).Elem())
+	if err = json.Unmarshal(b, v.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(target).Elem().Set(v.Elem())
+
+	return nil
+}
+
+// decode decodes a JSON document, keeping the numbers as they're written.
+func decode(b []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
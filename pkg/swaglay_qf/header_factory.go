@@ -0,0 +1,63 @@
+package swaglay_qf
+
+import (
+	"github.com/KoNekoD/swaglay/pkg/rest"
+)
+
+type HeaderParameter struct {
+	ParamName string
+	ParamData rest.HeaderParam
+}
+
+type CookieParameter struct {
+	ParamName string
+	ParamData rest.CookieParam
+}
+
+// NewHeaderParametersFromValue documents the fields of the struct that are bound from headers,
+// with the type and format of the field, the validator rules of its tag, and its doc comment.
+// A header is required if the validator requires the field.
+func NewHeaderParametersFromValue(v any) ([]HeaderParameter, error) {
+	fields, err := newParameterFields(v, rest.HeaderTag)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]HeaderParameter, 0, len(fields))
+	for _, field := range fields {
+		parameterData := rest.HeaderParam{
+			Description:       field.Description,
+			Type:              field.Type,
+			StructTag:         field.Field.Tag,
+			ApplyCustomSchema: field.ApplyCustomSchema,
+		}
+
+		parameters = append(parameters, HeaderParameter{ParamName: field.Name, ParamData: parameterData})
+	}
+
+	return parameters, nil
+}
+
+// NewCookieParametersFromValue documents the fields of the struct that are bound from cookies,
+// with the type and format of the field, the validator rules of its tag, and its doc comment.
+// A cookie is required if the validator requires the field.
+func NewCookieParametersFromValue(v any) ([]CookieParameter, error) {
+	fields, err := newParameterFields(v, rest.CookieTag)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]CookieParameter, 0, len(fields))
+	for _, field := range fields {
+		parameterData := rest.CookieParam{
+			Description:       field.Description,
+			Type:              field.Type,
+			StructTag:         field.Field.Tag,
+			ApplyCustomSchema: field.ApplyCustomSchema,
+		}
+
+		parameters = append(parameters, CookieParameter{ParamName: field.Name, ParamData: parameterData})
+	}
+
+	return parameters, nil
+}
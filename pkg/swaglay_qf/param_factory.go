@@ -0,0 +1,191 @@
+package swaglay_qf
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/getkin/kin-openapi/openapi3"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// ParameterField is a field of an input that is bound from a parameter, or from the request body.
+type ParameterField struct {
+	// Name of the parameter.
+	Name string
+	// Index sequence of the field, see reflect.Value.FieldByIndex.
+	Index []int
+	// Field is the struct field.
+	Field reflect.StructField
+	// Parent is the struct type that declares the field, which can be an embedded struct.
+	Parent reflect.Type
+}
+
+type parameterFieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var parameterFieldsCache sync.Map
+
+// GetParameterFields returns the fields of the struct type that are bound from the parameters named by the tag,
+// e.g. rest.PathTag, including the fields of embedded structs.
+func GetParameterFields(t reflect.Type, tag string) []ParameterField {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	key := parameterFieldsKey{t: t, tag: tag}
+	if fields, ok := parameterFieldsCache.Load(key); ok {
+		return fields.([]ParameterField)
+	}
+
+	var fields []ParameterField
+	collectParameterFields(t, tag, nil, &fields)
+	parameterFieldsCache.Store(key, fields)
+
+	return fields
+}
+
+func collectParameterFields(t reflect.Type, tag string, index []int, fields *[]ParameterField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(slices.Clone(index), i)
+
+		if name, ok := field.Tag.Lookup(tag); ok && field.IsExported() {
+			*fields = append(*fields, ParameterField{Name: name, Index: fieldIndex, Field: field, Parent: t})
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectParameterFields(field.Type, tag, fieldIndex, fields)
+		}
+	}
+}
+
+// GetBodyField returns the field of the struct type that the request body is bound to, the field tagged rest.BodyTag,
+// if the type is a composite input.
+func GetBodyField(t reflect.Type) (ParameterField, bool) {
+	fields := GetParameterFields(t, rest.BodyTag)
+	switch len(fields) {
+	case 0:
+		return ParameterField{}, false
+	case 1:
+		return fields[0], true
+	default:
+		panic(fmt.Sprintf("%s has %d fields tagged %s, only one can be bound to the request body", t, len(fields), rest.BodyTag))
+	}
+}
+
+// parameterField is the documentation of a field bound from a parameter.
+type parameterField struct {
+	ParameterField
+	Description string
+	Type        rest.PrimitiveType
+	// ApplyCustomSchema sets the format of the type of the field.
+	ApplyCustomSchema func(s *openapi3.Parameter)
+}
+
+// newParameterFields documents the fields of the struct that are bound from the parameters named by the tag,
+// with the type and format of the field, and its doc comment.
+func newParameterFields(v any, tag string) ([]parameterField, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("only structs can be converted to %s parameters", tag)
+	}
+
+	parameters := make([]parameterField, 0)
+	for _, field := range GetParameterFields(t, tag) {
+		fieldType := field.Field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		parameterType, format := resolveParameterType(fieldType)
+
+		parameters = append(parameters, parameterField{
+			ParameterField: field,
+			Description:    rest.GetFieldComment(field.Parent, field.Field.Name),
+			Type:           parameterType,
+			ApplyCustomSchema: func(s *openapi3.Parameter) {
+				if format != "" {
+					s.Schema.Value.Format = format
+				}
+			},
+		})
+	}
+
+	return parameters, nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// resolveParameterType returns the type and format of a parameter bound to a field of the type.
+// Types that implement encoding.TextUnmarshaler, such as uuid.UUID, are strings.
+func resolveParameterType(t reflect.Type) (rest.PrimitiveType, string) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		if t.PkgPath() == "github.com/google/uuid" && t.Name() == "UUID" {
+			return rest.PrimitiveTypeString, "uuid"
+		}
+		return rest.PrimitiveTypeString, ""
+	}
+
+	switch t.Kind() {
+	case reflect.Int64, reflect.Uint64:
+		return rest.PrimitiveTypeInteger, "int64"
+	case reflect.Int32, reflect.Uint32:
+		return rest.PrimitiveTypeInteger, "int32"
+	case reflect.Float32:
+		return rest.PrimitiveTypeFloat64, "float"
+	case reflect.Float64:
+		return rest.PrimitiveTypeFloat64, "double"
+	default:
+		return resolvePrimitiveSwaggerType(t.Kind().String()), ""
+	}
+}
+
+// SetParameterValue converts the value of a parameter to the type of the field, and sets the field.
+// Pointers are allocated, and types that implement encoding.TextUnmarshaler are decoded with it.
+func SetParameterValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", field.Type())
+	}
+
+	return nil
+}
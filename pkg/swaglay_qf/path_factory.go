@@ -0,0 +1,40 @@
+package swaglay_qf
+
+import (
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"reflect"
+)
+
+type PathParameter struct {
+	ParamName string
+	ParamData rest.PathParam
+}
+
+// GetPathFields returns the fields of the struct type that are bound from path parameters,
+// including the fields of embedded structs.
+func GetPathFields(t reflect.Type) []ParameterField {
+	return GetParameterFields(t, rest.PathTag)
+}
+
+// NewPathParametersFromValue documents the fields of the struct that are bound from path parameters,
+// with the type and format of the field, the validator rules of its tag, and its doc comment.
+func NewPathParametersFromValue(v any) ([]PathParameter, error) {
+	fields, err := newParameterFields(v, rest.PathTag)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]PathParameter, 0, len(fields))
+	for _, field := range fields {
+		parameterData := rest.PathParam{
+			Description:       field.Description,
+			Type:              field.Type,
+			StructTag:         field.Field.Tag,
+			ApplyCustomSchema: field.ApplyCustomSchema,
+		}
+
+		parameters = append(parameters, PathParameter{ParamName: field.Name, ParamData: parameterData})
+	}
+
+	return parameters, nil
+}
@@ -30,7 +30,12 @@ func NewQueryParametersFromValue(v any) ([]QueryParameter, error) {
 			}
 		}
 
-		parameterData := rest.QueryParam{Required: !itemValue.CanBeNil, Type: parameterType, ApplyCustomSchema: custom}
+		// A query parameter is only required if the validator requires the field, see rest.API.ValidateTagName.
+		parameterData := rest.QueryParam{
+			Type:              parameterType,
+			ApplyCustomSchema: custom,
+			StructTag:         itemValue.Tag,
+		}
 
 		parameter := QueryParameter{ParamName: propertyPath, ParamData: parameterData}
 
@@ -39,6 +44,30 @@ func NewQueryParametersFromValue(v any) ([]QueryParameter, error) {
 	return parameters, nil
 }
 
+// NewTaggedQueryParametersFromValue documents the fields of the struct that are bound from the query parameters
+// of their tag, e.g. `query:"notify"`, with the type and format of the field, the validator rules of its tag,
+// and its doc comment. A query parameter is required if the validator requires the field.
+func NewTaggedQueryParametersFromValue(v any) ([]QueryParameter, error) {
+	fields, err := newParameterFields(v, rest.QueryTag)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]QueryParameter, 0, len(fields))
+	for _, field := range fields {
+		parameterData := rest.QueryParam{
+			Description:       field.Description,
+			Type:              field.Type,
+			StructTag:         field.Field.Tag,
+			ApplyCustomSchema: field.ApplyCustomSchema,
+		}
+
+		parameters = append(parameters, QueryParameter{ParamName: field.Name, ParamData: parameterData})
+	}
+
+	return parameters, nil
+}
+
 func resolvePrimitiveSwaggerType(s string) rest.PrimitiveType {
 	// Not supported types:
 	//  * array: No associative array exists in Go,
@@ -100,6 +129,8 @@ func getFieldTypeName(field reflect.StructField) string {
 type FlattenedItemValue struct {
 	Value    reflect.Value
 	CanBeNil bool
+	// Tag of the struct field, empty for the elements of slices and arrays.
+	Tag reflect.StructTag
 }
 
 func flattenStruct(input any) map[string]FlattenedItemValue {
@@ -109,7 +140,7 @@ func flattenStruct(input any) map[string]FlattenedItemValue {
 	return result
 }
 
-func setResult(value reflect.Value, key string, result map[string]FlattenedItemValue) {
+func setResult(value reflect.Value, key string, tag reflect.StructTag, result map[string]FlattenedItemValue) {
 	canBeNil := false
 	fieldKind := value.Kind()
 
@@ -121,7 +152,7 @@ func setResult(value reflect.Value, key string, result map[string]FlattenedItemV
 		value = reflect.New(fieldPointerElem).Elem()
 		canBeNil = true
 		if isKindPrimitive(fieldKind) {
-			result[key] = FlattenedItemValue{Value: value, CanBeNil: canBeNil}
+			result[key] = FlattenedItemValue{Value: value, CanBeNil: canBeNil, Tag: tag}
 		} else {
 			flatten(value, key, result)
 		}
@@ -141,7 +172,10 @@ func setResult(value reflect.Value, key string, result map[string]FlattenedItemV
 			flatten(value, key+"[]", result)
 		}
 	default:
-		break
+		// Value structs, e.g. time.Time, aren't flattened, as they can't be bound from the query string.
+		if isKindPrimitive(fieldKind) {
+			result[key] = FlattenedItemValue{Value: value, CanBeNil: canBeNil, Tag: tag}
+		}
 	}
 }
 
@@ -149,6 +183,23 @@ func flattenValueStruct(value reflect.Value, prefix string, result map[string]Fl
 	for i := 0; i < value.NumField(); i++ {
 		field := value.Field(i)
 		fieldType := value.Type().Field(i)
+
+		// Fields of embedded structs are promoted, like in encoding/json, unless they're named by a json tag.
+		if fieldType.Anonymous && fieldType.Tag.Get("json") == "" {
+			embeddedType := fieldType.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				flatten(reflect.New(embeddedType).Elem(), prefix, result)
+				continue
+			}
+		}
+		// Fields bound from parameters are documented from their tags.
+		if !fieldType.IsExported() || rest.IsParameterField(fieldType) {
+			continue
+		}
+
 		fieldName := getFieldTypeName(fieldType)
 
 		key := fieldName
@@ -156,7 +207,7 @@ func flattenValueStruct(value reflect.Value, prefix string, result map[string]Fl
 			key = prefix + "[" + fieldName + "]"
 		}
 
-		setResult(field, key, result)
+		setResult(field, key, fieldType.Tag, result)
 	}
 }
 
@@ -192,7 +243,7 @@ func flatten(value reflect.Value, prefix string, result map[string]FlattenedItem
 	case reflect.Invalid, reflect.Chan, reflect.Func, reflect.Interface:
 		panic("Type is not supported for flattening:" + valueKind.String())
 	case reflect.Array, reflect.Slice:
-		setResult(value, prefix, result)
+		setResult(value, prefix, "", result)
 	default:
 		panic("unknown kind:" + valueKind.String())
 	}
@@ -1,16 +1,35 @@
 package swaglay_fiber
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	swaglay "github.com/KoNekoD/swaglay/pkg"
 	"github.com/KoNekoD/swaglay/pkg/adapters/swaglay_fiber"
+	"github.com/KoNekoD/swaglay/pkg/dtos"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_golden"
+	"github.com/KoNekoD/swaglay/pkg/swaglay_patch"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
 	"github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -64,6 +83,127 @@ func (f *fieldError) Error() string {
 	return string(*f)
 }
 
+// CommentedOut is documented from its doc comments.
+type CommentedOut struct {
+	// Name of the resource.
+	Name string `json:"name"`
+	// Legacy name of the resource.
+	//
+	// Deprecated: use name instead.
+	LegacyName string `json:"legacyName"`
+	Count      int    `json:"count"` // Count of the resources.
+}
+
+// Post and Comment are mutually recursive.
+type Post struct {
+	Comments []Comment `json:"comments"`
+}
+
+type Comment struct {
+	Post    *Post      `json:"post"`
+	Replies []*Comment `json:"replies"`
+}
+
+// Pagination is embedded in the inputs of the lists.
+type Pagination struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit" binding:"required"`
+}
+
+// Sorting is embedded by pointer in the inputs of the lists.
+type Sorting struct {
+	Sort string `json:"sort"`
+}
+
+// ListIn is the input of a list, its query parameters are promoted from the embedded structs.
+type ListIn struct {
+	Pagination
+	*Sorting
+	Search string `json:"search"`
+}
+
+// PathIn is bound from the path, the other fields from the body or the query.
+type PathIn struct {
+	// ID of the resource.
+	ID   int       `path:"id" binding:"min=1"`
+	Key  uuid.UUID `path:"key"`
+	Name string    `json:"name"`
+}
+
+// CreateOrgUser is the body of CompositeIn.
+type CreateOrgUser struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CompositeIn mixes the body with path, query and header parameters.
+type CompositeIn struct {
+	Body  CreateOrgUser `body:""`
+	OrgID int           `path:"orgId"`
+	// Notify the user by email.
+	Notify   *bool `query:"notify"`
+	TenantID int   `header:"X-Tenant-Id"`
+}
+
+// UploadAvatar is a multipart form with a file.
+type UploadAvatar struct {
+	Avatar rest.File `form:"avatar" accept:"image/png,image/jpeg" maxSize:"1KB"`
+	Alt    string    `form:"alt" binding:"required"`
+	Tags   []string  `form:"tags"`
+}
+
+// Subscribe is a form without files.
+type Subscribe struct {
+	Email string `form:"email" binding:"required"`
+	Count int    `form:"count"`
+}
+
+// HeaderIn is bound from the headers and the cookies, the other fields from the body or the query.
+type HeaderIn struct {
+	// Tenant of the request.
+	TenantID       int        `header:"X-Tenant-Id" binding:"required,min=1"`
+	IdempotencyKey *uuid.UUID `header:"Idempotency-Key"`
+	Locale         string     `header:"Accept-Language"`
+	// Session of the user.
+	Session string `cookie:"session" binding:"required"`
+	Name    string `json:"name"`
+}
+
+// Order is an order.
+type Order struct {
+	ID string `json:"id"`
+}
+
+// Job is a job processing a request.
+type Job struct {
+	ID string `json:"id"`
+}
+
+// Conflict is the error of a request conflicting with the state of a resource.
+type Conflict struct {
+	Reason string `json:"reason"`
+}
+
+// OrderResponses are the typed responses of an operation.
+type OrderResponses struct {
+	swaglay.Responses
+	OK       *Order         `status:"200"`
+	Accepted *Job           `status:"202"`
+	Conflict *Conflict      `status:"409"`
+	NotFound *dtos.NotFound `status:"404"`
+}
+
+// ErrOrderNotFound is returned for an order that doesn't exist.
+var ErrOrderNotFound = errors.New("order not found")
+
+// ConflictError is returned for a request conflicting with the state of a resource.
+type ConflictError struct {
+	Resource string `json:"resource"`
+}
+
+func (e *ConflictError) Error() string {
+	return e.Resource + " conflicts"
+}
+
 var AppValidatorInstance *AppValidator
 
 type AppValidator struct {
@@ -217,7 +357,7 @@ func TestHttp(t *testing.T) {
 		content := string(responseBytes)
 
 		if response.StatusCode != status {
-			t.Fatalf("expected status code %d, got %d content %s", fiber.StatusOK, response.StatusCode, content)
+			t.Fatalf("expected status code %d, got %d content %s", status, response.StatusCode, content)
 		}
 
 		return content
@@ -304,18 +444,18 @@ func TestHttp(t *testing.T) {
 			sendRequest(fiberApp, fiber.MethodGet, addLeadingSlash(getIUrl+getDataInQueryString()))
 			sendRequest(fiberApp, fiber.MethodGet, addLeadingSlash(getOUrl+getDataInQueryString()))
 			sendRequest(fiberApp, fiber.MethodGet, addLeadingSlash(getIOUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postIUrl), getDataInBodyReader())
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postOUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postIOUrl), getDataInBodyReader())
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postUrl+getDataInQueryString()), fiber.StatusCreated)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postIUrl), fiber.StatusCreated, getDataInBodyReader())
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postOUrl+getDataInQueryString()), fiber.StatusCreated)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postIOUrl), fiber.StatusCreated, getDataInBodyReader())
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putUrl+getDataInQueryString()))
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putIUrl), getDataInBodyReader())
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putOUrl+getDataInQueryString()))
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putIOUrl), getDataInBodyReader())
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteOUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIOUrl+getDataInQueryString()))
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteUrl+getDataInQueryString()), fiber.StatusNoContent)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIUrl+getDataInQueryString()), fiber.StatusNoContent)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteOUrl+getDataInQueryString()), fiber.StatusOK)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIOUrl+getDataInQueryString()), fiber.StatusOK)
 		},
 	)
 
@@ -419,12 +559,12 @@ func TestHttp(t *testing.T) {
 
 			sendRequest(fiberApp, fiber.MethodGet, addLeadingSlash(getIUrl+getDataInQueryString()))
 			sendRequest(fiberApp, fiber.MethodGet, addLeadingSlash(getIOUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postIUrl), getDataInBodyReader())
-			sendRequest(fiberApp, fiber.MethodPost, addLeadingSlash(postIOUrl), getDataInBodyReader())
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postIUrl), fiber.StatusCreated, getDataInBodyReader())
+			sendRequestExpectedStatus(fiberApp, fiber.MethodPost, addLeadingSlash(postIOUrl), fiber.StatusCreated, getDataInBodyReader())
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putIUrl), getDataInBodyReader())
 			sendRequest(fiberApp, fiber.MethodPut, addLeadingSlash(putIOUrl), getDataInBodyReader())
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIUrl+getDataInQueryString()))
-			sendRequest(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIOUrl+getDataInQueryString()))
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIUrl+getDataInQueryString()), fiber.StatusNoContent)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, addLeadingSlash(deleteIOUrl+getDataInQueryString()), fiber.StatusOK)
 		},
 	)
 
@@ -483,15 +623,15 @@ func TestHttp(t *testing.T) {
 
 			for pattern, route := range a.Routes {
 				for method, r := range route {
+					// HEAD routes documented for GET routes have no response body.
+					if method == fiber.MethodHead {
+						continue
+					}
+
 					var responseModelString string
 
-					successCode := fiber.StatusOK
-					if method == fiber.MethodPost {
-						successCode = fiber.StatusCreated
-					}
-					if method == fiber.MethodDelete {
-						successCode = fiber.StatusNoContent
-					}
+					// Every route has an output, so DELETE routes respond with 200 too.
+					successCode := swaglay.SuccessStatus(string(method), true)
 
 					responseModelString = r.Models.Responses[successCode].Type.String()
 
@@ -528,7 +668,9 @@ func TestHttp(t *testing.T) {
 			deleteIOUrl := getApiUrl()
 			swaglay_fiber.DeleteIO(api, deleteIOUrl, fnIO, getName())
 
-			const excepted = `{"error":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag"}`
+			const excepted = `{"error":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag",` +
+				`"fields":[{"field":"name","tag":"ne","param":"ttt",` +
+				`"message":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag"}]}`
 
 			firstErr := sendRequestExpectedStatus(
 				fiberApp,
@@ -625,7 +767,9 @@ func TestHttp(t *testing.T) {
 			postIOUrl := getApiUrl()
 			swaglay_fiber.PostIO(api, postIOUrl, fnIO, getName(), opts)
 
-			const excepted = `{"error":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag"}`
+			const excepted = `{"error":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag",` +
+				`"fields":[{"field":"name","tag":"ne","param":"ttt",` +
+				`"message":"Key: 'DataIn.Name' Error:Field validation for 'Name' failed on the 'ne' tag"}]}`
 
 			firstErr := sendRequestExpectedStatus(
 				fiberApp,
@@ -710,11 +854,2009 @@ func TestHttp(t *testing.T) {
 				fiber.StatusUnprocessableEntity,
 			)
 
-			const excepted = `{"error":"test error"}`
+			const excepted = `{"error":"test error","fields":[{"field":"name","tag":"required","param":"123","message":"test error"}]}`
 
 			if errorContent != excepted {
 				t.Errorf("expected %s, got %s", excepted, errorContent)
 			}
 		},
 	)
+
+	t.Run(
+		"test serve spec",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			getIOUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetIO(api, getIOUrl, fnIO, getName())
+
+			if err := swaglay_fiber.ServeSpec(fiberApp.Group("/docs")); err != nil {
+				t.Fatalf("failed to serve spec: %s", err)
+			}
+
+			for path, contentType := range map[string]string{
+				"/docs/openapi.json": swaglay_fiber.JsonContentType,
+				"/docs/openapi.yaml": swaglay_fiber.YamlContentType,
+			} {
+				request, _ := http.NewRequest(fiber.MethodGet, path, nil)
+				response, err := fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				if response.StatusCode != fiber.StatusOK {
+					t.Fatalf("expected status code %d, got %d", fiber.StatusOK, response.StatusCode)
+				}
+				if got := response.Header.Get(fiber.HeaderContentType); got != contentType {
+					t.Errorf("expected content type %s, got %s", contentType, got)
+				}
+				body, _ := io.ReadAll(response.Body)
+				if !strings.Contains(string(body), getIOUrl) {
+					t.Errorf("expected %s to document %s, got %s", path, getIOUrl, body)
+				}
+
+				etag := response.Header.Get(fiber.HeaderETag)
+				if etag == "" || response.Header.Get(fiber.HeaderLastModified) == "" {
+					t.Fatalf("expected ETag and Last-Modified headers on %s", path)
+				}
+
+				request, _ = http.NewRequest(fiber.MethodGet, path, nil)
+				request.Header.Set(fiber.HeaderIfNoneMatch, etag)
+				response, err = fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				if response.StatusCode != fiber.StatusNotModified {
+					t.Errorf("expected status code %d, got %d", fiber.StatusNotModified, response.StatusCode)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test openapi 3.1",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithOpenAPIVersion(rest.OpenAPIVersion31))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			type NullableOut struct {
+				Name  *string `json:"name"`
+				Count int     `json:"count" validate:"gt=0"`
+			}
+
+			swaglay.Api.MustRegisterModel(rest.ModelOf[NullableOut](), rest.WithExamples(map[string]any{"name": nil, "count": 1}))
+
+			getOUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetO(
+				api, getOUrl, func(ctx fiber.Ctx) (*NullableOut, error) { return nil, nil }, getName(),
+			)
+
+			for range 2 {
+				content, err := swaglay.Api.SpecJSON()
+				if err != nil {
+					t.Fatalf("failed to create spec: %s", err)
+				}
+
+				for _, excepted := range []string{
+					`"openapi":"3.1.0"`,
+					`"name":{"type":["string","null"]}`,
+					`"examples":[{"count":1,"name":null}]`,
+					`"count":{"exclusiveMinimum":0,"type":"integer"}`,
+				} {
+					if !strings.Contains(string(content), excepted) {
+						t.Errorf("expected %s in %s", excepted, content)
+					}
+				}
+				if strings.Contains(string(content), `"nullable"`) {
+					t.Errorf("expected no nullable keyword in %s", content)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test security",
+		func(t *testing.T) {
+			swaglay.SetupApi(
+				api,
+				rest.WithSecurityScheme("bearer", rest.NewJWTSecurityScheme()),
+				rest.WithSecurityScheme("apiKey", rest.NewAPIKeySecurityScheme(rest.APIKeyInHeader, "X-Api-Key")),
+			)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			getUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.Get(
+				api, getUrl, fn, getName(), swaglay_fiber.Opts{
+					Security: []rest.SecurityRequirement{{"bearer": {}}, {"apiKey": {}}},
+				},
+			)
+
+			content, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, excepted := range []string{
+				`"bearer":{"bearerFormat":"JWT","scheme":"bearer","type":"http"}`,
+				`"apiKey":{"in":"header","name":"X-Api-Key","type":"apiKey"}`,
+				`"security":[{"bearer":[]},{"apiKey":[]}]`,
+			} {
+				if !strings.Contains(string(content), excepted) {
+					t.Errorf("expected %s in %s", excepted, content)
+				}
+			}
+
+			swaglay.Api.Get(getUrl).HasSecurity("unknown")
+			if _, err = swaglay.Api.Spec(); err == nil {
+				t.Errorf("expected error for unknown security scheme")
+			}
+		},
+	)
+
+	t.Run(
+		"test document info",
+		func(t *testing.T) {
+			swaglay.SetupApi(
+				api,
+				rest.WithVersion("1.2.3"),
+				rest.WithAPIDescription("Test API"),
+				rest.WithTermsOfService("https://example.com/terms"),
+				rest.WithContact("Team", "https://example.com", "team@example.com"),
+				rest.WithLicense("MIT", "https://opensource.org/licenses/MIT"),
+				rest.WithExternalDocs("https://example.com/docs", "Guides"),
+				rest.WithServer(
+					rest.Server{
+						URL: "https://{env}.example.com",
+						Variables: map[string]rest.ServerVariable{
+							"env": {Default: "prod", Enum: []string{"prod", "staging"}},
+						},
+					},
+				),
+			)
+
+			content, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, excepted := range []string{
+				`"version":"1.2.3"`,
+				`"description":"Test API"`,
+				`"termsOfService":"https://example.com/terms"`,
+				`"contact":{"email":"team@example.com","name":"Team","url":"https://example.com"}`,
+				`"license":{"name":"MIT","url":"https://opensource.org/licenses/MIT"}`,
+				`"externalDocs":{"description":"Guides","url":"https://example.com/docs"}`,
+				`"servers":[{"url":"https://{env}.example.com","variables":{"env":{"default":"prod","enum":["prod","staging"]}}}]`,
+			} {
+				if !strings.Contains(string(content), excepted) {
+					t.Errorf("expected %s in %s", excepted, content)
+				}
+			}
+
+			swaglay.SetupApi(api, rest.WithServer(rest.Server{URL: "https://{env}.example.com"}))
+			if _, err = swaglay.Api.Spec(); err == nil {
+				t.Errorf("expected error for undeclared server variable")
+			}
+		},
+	)
+
+	t.Run(
+		"test doc comments",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			getOUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetO(
+				api, getOUrl, func(ctx fiber.Ctx) (*CommentedOut, error) { return nil, nil }, getName(),
+			)
+
+			content, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, excepted := range []string{
+				`"description":"CommentedOut is documented from its doc comments."`,
+				`"name":{"description":"Name of the resource.","type":"string"}`,
+				`"legacyName":{"deprecated":true,"description":"Legacy name of the resource.\n\nDeprecated: use name instead.","type":"string"}`,
+				`"count":{"description":"Count of the resources.","type":"integer"}`,
+			} {
+				if !strings.Contains(string(content), excepted) {
+					t.Errorf("expected %s in %s", excepted, content)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test validator constraints",
+		func(t *testing.T) {
+			swaglay.SetupApi(
+				api,
+				rest.WithValidateTagName("binding"),
+				rest.WithValidateTag(
+					"slug", func(s *openapi3.Schema, _ string) {
+						s.Pattern = "^[a-z0-9-]+$"
+					},
+				),
+			)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			type ConstrainedIn struct {
+				Name   string   `json:"name,omitempty" binding:"required,min=3,max=64"`
+				Email  string   `json:"email" binding:"email"`
+				Kind   string   `json:"kind" binding:"oneof=a b 'c d'"`
+				Age    int      `json:"age" binding:"gt=0,lte=150"`
+				Tags   []string `json:"tags" binding:"min=1,dive,min=2"`
+				Slug   string   `json:"slug" binding:"slug"`
+				Number *float64 `json:"number,omitempty" binding:"lt=10"`
+				Birth  string   `json:"birth,omitempty" binding:"datetime=2006-01-02"`
+				At     string   `json:"at,omitempty" binding:"datetime=2006-01-02T15:04:05Z07:00"`
+				Clock  string   `json:"clock,omitempty" binding:"datetime=15:04"`
+				Code   string   `json:"code,omitempty" binding:"alphanum"`
+				Color  string   `json:"color,omitempty" binding:"hexcolor"`
+				Count  int      `json:"count,omitempty" binding:"numeric"`
+			}
+
+			postIUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostI(api, postIUrl, func(input *ConstrainedIn, ctx fiber.Ctx) error { return nil }, getName())
+			getIUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetI(api, getIUrl, func(input *ConstrainedIn, ctx fiber.Ctx) error { return nil }, getName())
+
+			content, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, excepted := range []string{
+				`"name":{"maxLength":64,"minLength":3,"type":"string"}`,
+				`"email":{"format":"email","type":"string"}`,
+				`"kind":{"enum":["a","b","c d"],"type":"string"}`,
+				`"age":{"exclusiveMinimum":true,"maximum":150,"minimum":0,"type":"integer"}`,
+				`"minItems":1`,
+				`"slug":{"pattern":"^[a-z0-9-]+$","type":"string"}`,
+				`"number":{"exclusiveMaximum":true,"maximum":10,"nullable":true,"type":"number"}`,
+				`"birth":{"format":"date","type":"string"}`,
+				`"at":{"format":"date-time","type":"string"}`,
+				`"clock":{"type":"string"}`,
+				`"code":{"pattern":"^[a-zA-Z0-9]+$","type":"string"}`,
+				`"color":{"pattern":"^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$","type":"string"}`,
+				`"count":{"type":"integer"}`,
+				`"required":["name","email","kind","age","tags","slug"]`,
+				`{"in":"query","name":"name","required":true,"schema":{"maxLength":64,"minLength":3,"type":"string"}}`,
+			} {
+				if !strings.Contains(string(content), excepted) {
+					t.Errorf("expected %s in %s", excepted, content)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test recursive types",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+
+			type TreeNode struct {
+				Name     string     `json:"name"`
+				Children []TreeNode `json:"children"`
+			}
+			type ListNode struct {
+				Value int       `json:"value"`
+				Next  *ListNode `json:"next"`
+			}
+
+			for _, model := range []rest.Model{
+				rest.ModelOf[TreeNode](),
+				rest.ModelOf[ListNode](),
+				rest.ModelOf[Comment](),
+				rest.ModelOf[Post](),
+			} {
+				if _, _, err := swaglay.Api.RegisterModel(model); err != nil {
+					t.Fatalf("failed to register %s: %s", model.Type, err)
+				}
+			}
+
+			swaglay.Api.Get("/tree").HasResponseModel(http.StatusOK, rest.ModelOf[TreeNode]())
+			swaglay.Api.Get("/list").HasResponseModel(http.StatusOK, rest.ModelOf[ListNode]())
+			swaglay.Api.Get("/post").HasResponseModel(http.StatusOK, rest.ModelOf[Post]())
+
+			content, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, excepted := range []string{
+				`"children":{"items":{"$ref":"#/components/schemas/TreeNode"},"nullable":true,"type":"array"}`,
+				`"next":{"$ref":"#/components/schemas/ListNode"}`,
+				`"replies":{"items":{"$ref":"#/components/schemas/Comment"},"nullable":true,"type":"array"}`,
+				`"comments":{"items":{"$ref":"#/components/schemas/Comment"},"nullable":true,"type":"array"}`,
+				`"post":{"$ref":"#/components/schemas/Post"}`,
+			} {
+				if !strings.Contains(string(content), excepted) {
+					t.Errorf("expected %s in %s", excepted, content)
+				}
+			}
+
+			inlinedApi := rest.NewAPI("inlined", rest.WithApplyCustomSchemaToType(func(t reflect.Type, s *openapi3.Schema) {
+				if t == reflect.TypeFor[Comment]() {
+					s.AdditionalProperties.Schema = openapi3.NewSchemaRef("", openapi3.NewStringSchema())
+				}
+			}))
+			if _, _, err = inlinedApi.RegisterModel(rest.ModelOf[Post]()); err == nil {
+				t.Errorf("expected an error for a recursive type customised into a schema that's not referenced")
+			}
+		},
+	)
+
+	t.Run(
+		"test json tags",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+
+			type Base struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			}
+			type Audit struct {
+				CreatedBy string `json:"createdBy"`
+				Name      string `json:"name"`
+			}
+			type JsonTagsOut struct {
+				Base
+				*Audit
+				ID     string `json:"id"`
+				Secret string `json:"-"`
+				Dash   string `json:"-,"`
+				Count  int64  `json:"count,string"`
+				Flag   bool   `json:"flag,string,omitempty"`
+				Zero   int    `json:"zero,omitzero"`
+			}
+
+			_, schema, err := swaglay.Api.RegisterModel(rest.ModelOf[JsonTagsOut]())
+			if err != nil {
+				t.Fatalf("failed to register model: %s", err)
+			}
+
+			properties := make(map[string]string)
+			for name, ref := range schema.Properties {
+				properties[name] = ref.Value.Type.Slice()[0]
+			}
+			exceptedProperties := map[string]string{
+				"id":        "string",
+				"-":         "string",
+				"count":     "string",
+				"flag":      "string",
+				"zero":      "integer",
+				"createdBy": "string",
+			}
+			if !reflect.DeepEqual(properties, exceptedProperties) {
+				t.Errorf("expected properties %v, got %v", exceptedProperties, properties)
+			}
+
+			exceptedRequired := []string{"id", "-", "count"}
+			if !reflect.DeepEqual(schema.Required, exceptedRequired) {
+				t.Errorf("expected required %v, got %v", exceptedRequired, schema.Required)
+			}
+		},
+	)
+
+	t.Run(
+		"test query parameters of embedded structs",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			url := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetIO(api, url, func(input *ListIn, ctx fiber.Ctx) (*ListIn, error) { return input, nil }, getName())
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			operation := spec.Paths.Value(url).Get
+			for name, required := range map[string]bool{"page": false, "limit": true, "sort": false, "search": false} {
+				parameter := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, name)
+				if parameter == nil || parameter.Required != required {
+					t.Errorf("expected the query parameter %s, required %t, got %+v", name, required, parameter)
+				}
+			}
+			for _, name := range []string{"Pagination", "Sorting", "Pagination[page]", "Sorting[sort]"} {
+				if operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, name) != nil {
+					t.Errorf("expected the embedded struct %s not to be a query parameter", name)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test deterministic spec",
+		func(t *testing.T) {
+			type Item struct {
+				Name string `json:"name"`
+			}
+			type Anonymous = struct {
+				Items []Item `json:"items"`
+				Meta  struct {
+					Total int `json:"total"`
+				} `json:"meta"`
+			}
+
+			setup := func(reversed bool) {
+				swaglay.SetupApi(api, rest.WithVersion("1.0.0"))
+				registrations := []func(){
+					func() { swaglay.RegisterHandlerO[Anonymous]("items", "/items", http.MethodGet, "list items") },
+					func() { swaglay.RegisterHandlerIO[Item, *Item]("items", "/items", http.MethodPost, "create item") },
+					func() { swaglay.RegisterHandlerO[*Item]("items", "/items/{id}", http.MethodGet, "get item") },
+					func() { swaglay.RegisterHandler("items", "/items/{id}", http.MethodDelete, "delete item") },
+				}
+				if reversed {
+					slices.Reverse(registrations)
+				}
+				for _, register := range registrations {
+					register()
+				}
+			}
+
+			setup(false)
+			first, err := swaglay.Api.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			second, _ := swaglay.Api.SpecJSON()
+
+			setup(true)
+			reversed, _ := swaglay.Api.SpecJSON()
+
+			if !bytes.Equal(first, second) || !bytes.Equal(first, reversed) {
+				t.Errorf("expected identical specs, got:\n%s\n%s\n%s", first, second, reversed)
+			}
+
+			swaglay_golden.AssertSpec(t, swaglay.Api, "testdata/spec.golden.json")
+		},
+	)
+
+	t.Run(
+		"test spec cache",
+		func(t *testing.T) {
+			type CachedOut struct {
+				Name string `json:"name"`
+			}
+
+			swaglay.SetupApi(api)
+			swaglay.RegisterHandlerO[CachedOut]("cache", "/cached", http.MethodGet, "get cached")
+
+			first, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			if second, _ := swaglay.Api.Spec(); second != first {
+				t.Errorf("expected the spec to be reused")
+			}
+
+			swaglay.Api.Get("/cached/{id}").HasResponseModel(http.StatusOK, rest.ModelOf[CachedOut]())
+			afterRoute, _ := swaglay.Api.Spec()
+			if afterRoute == first || afterRoute.Paths.Value("/cached/{id}") == nil {
+				t.Errorf("expected the spec to be rebuilt after adding a route")
+			}
+
+			_, schema := swaglay.Api.MustRegisterModel(rest.ModelOf[CachedOut]())
+			schema.Description = "Cached resource"
+			afterModel, _ := swaglay.Api.Spec()
+			if afterModel == afterRoute || afterModel.Components.Schemas["CachedOut"].Value.Description != "Cached resource" {
+				t.Errorf("expected the spec to be rebuilt after registering a model")
+			}
+
+			swaglay.Api.Version = "2.0.0"
+			swaglay.Api.InvalidateSpec()
+			if afterInvalidate, _ := swaglay.Api.Spec(); afterInvalidate.Info.Version != "2.0.0" {
+				t.Errorf("expected the spec to be rebuilt after invalidation")
+			}
+		},
+	)
+
+	t.Run(
+		"test concurrent registration",
+		func(t *testing.T) {
+			type ConcurrentIn struct {
+				Name string `json:"name"`
+			}
+			type ConcurrentOut struct {
+				Name string `json:"name"`
+			}
+
+			swaglay.SetupApi(api)
+
+			const modules, routes = 4, 10
+
+			var wg sync.WaitGroup
+			for m := range modules {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for i := range routes {
+						resource := fmt.Sprintf("module%d", m)
+						url := fmt.Sprintf("/%s/items%d", resource, i)
+						swaglay.RegisterHandlerIO[ConcurrentIn, ConcurrentOut](resource, url, http.MethodPost, "create "+url)
+						swaglay.RegisterHandlerO[ConcurrentOut](resource, url+"/{id}", http.MethodGet, "get "+url)
+						swaglay.RegisterHandler(resource, url+"/{id}", http.MethodDelete, "delete "+url)
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for range routes {
+						if _, err := swaglay.Api.SpecJSON(); err != nil {
+							t.Errorf("failed to create spec: %s", err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			if excepted := modules * routes * 2; spec.Paths.Len() != excepted {
+				t.Errorf("expected %d paths, got %d", excepted, spec.Paths.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"test multiple apis",
+		func(t *testing.T) {
+			type AdminOut struct {
+				Name string `json:"name"`
+			}
+
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			publicApi := swaglay.NewApi("public", rest.WithVersion("1.0.0"))
+			adminApi := swaglay.NewApi("admin", rest.WithVersion("2.0.0"))
+
+			publicUrl, adminUrl, globalUrl := "/multiple-apis/public", "/multiple-apis/admin", "/multiple-apis/global"
+			swaglay_fiber.GetIO(api, publicUrl, fnIO, getName(), swaglay_fiber.Opts{Api: publicApi})
+			swaglay_fiber.GetO(
+				api, adminUrl, func(ctx fiber.Ctx) (*AdminOut, error) { return &AdminOut{}, nil }, getName(),
+				swaglay_fiber.Opts{Api: adminApi},
+			)
+			swaglay.RegisterHandlerFor(adminApi, api, adminUrl, http.MethodDelete, getName())
+			swaglay_fiber.GetO(api, globalUrl, fnO, getName())
+
+			for _, tc := range []struct {
+				prefix     string
+				api        *rest.API
+				documented []string
+				hidden     []string
+			}{
+				{"/docs-public", publicApi, []string{publicUrl, `"title":"public"`, "DataOut"}, []string{adminUrl, globalUrl, "AdminOut"}},
+				{"/docs-admin", adminApi, []string{adminUrl, `"title":"admin"`, "AdminOut", `"delete"`}, []string{publicUrl, globalUrl, "DataOut"}},
+				{"/docs-global", swaglay.Api, []string{globalUrl, "DataOut"}, []string{publicUrl, adminUrl, "AdminOut"}},
+			} {
+				if err := swaglay_fiber.ServeApiSpec(fiberApp.Group(tc.prefix), tc.api); err != nil {
+					t.Fatalf("failed to serve spec: %s", err)
+				}
+
+				content := sendRequestExpectedStatus(fiberApp, fiber.MethodGet, tc.prefix+"/openapi.json", fiber.StatusOK)
+				for _, excepted := range tc.documented {
+					if !strings.Contains(content, excepted) {
+						t.Errorf("expected %s in %s spec %s", excepted, tc.prefix, content)
+					}
+				}
+				for _, unexpected := range tc.hidden {
+					if strings.Contains(content, unexpected) {
+						t.Errorf("expected no %s in %s spec %s", unexpected, tc.prefix, content)
+					}
+				}
+			}
+
+			sendRequestExpectedStatus(fiberApp, fiber.MethodGet, adminUrl, fiber.StatusOK)
+		},
+	)
+
+	t.Run(
+		"test registrar",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			errTeapot := fmt.Errorf("teapot")
+			var handledErrors []error
+
+			registrarApi := swaglay.NewApi("registrar")
+			registrar := swaglay_fiber.NewRegistrar(
+				fiberApp,
+				registrarApi,
+				swaglay_fiber.WithErrorMapper(
+					func(ctx fiber.Ctx, err error) (int, any) {
+						return fiber.StatusTeapot, map[string]string{"message": err.Error()}
+					},
+				),
+				swaglay_fiber.WithErrorBody(
+					func(ctx fiber.Ctx, err error) any {
+						return map[string]string{"message": "invalid input"}
+					},
+				),
+				swaglay_fiber.WithOnHandleError(
+					func(ctx fiber.Ctx, err error) {
+						handledErrors = append(handledErrors, err)
+					},
+				),
+			)
+
+			v1 := registrar.Group("/registrar/").Group("v1")
+			items := v1.Group("/items")
+			items.Get(api, "/{id}", swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*DataOut, error) {
+				return &DataOut{Name: ctx.Params("id")}, nil
+			}), "registrar get item")
+			items.Post(api, "", swaglay_fiber.HandleIO(func(input *DataIn, ctx fiber.Ctx) (*DataOut, error) {
+				return &DataOut{Name: input.Name}, nil
+			}), "registrar create item")
+			items.Delete(api, "/{id}", swaglay_fiber.Handle(func(ctx fiber.Ctx) error {
+				return errTeapot
+			}), "registrar delete item")
+			v1.Put(api, "/status", swaglay_fiber.HandleI(fnI), "registrar put status")
+
+			spec, err := registrarApi.SpecJSON()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			for _, excepted := range []string{
+				`"/registrar/v1/items/{id}":{"delete"`,
+				`"/registrar/v1/items":{"post"`,
+				`"/registrar/v1/status":{"put"`,
+			} {
+				if !strings.Contains(string(spec), excepted) {
+					t.Errorf("expected %s in %s", excepted, spec)
+				}
+			}
+			if globalSpec, _ := swaglay.Api.SpecJSON(); strings.Contains(string(globalSpec), "/registrar") {
+				t.Errorf("expected the registrar routes not to be documented in swaglay.Api, got %s", globalSpec)
+			}
+
+			content := sendRequest(fiberApp, fiber.MethodGet, "/registrar/v1/items/42")
+			if content != `{"name":"42"}` {
+				t.Errorf("unexpected response %s", content)
+			}
+			content = sendRequestExpectedStatus(
+				fiberApp, fiber.MethodPost, "/registrar/v1/items", fiber.StatusCreated, getDataInBodyReader(),
+			)
+			if content != `{"name":"test"}` {
+				t.Errorf("unexpected response %s", content)
+			}
+			content = sendRequestExpectedStatus(
+				fiberApp, fiber.MethodPut, "/registrar/v1/status", fiber.StatusUnprocessableEntity, strings.NewReader("{"),
+			)
+			if content != `{"message":"invalid input"}` {
+				t.Errorf("unexpected response %s", content)
+			}
+			content = sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, "/registrar/v1/items/42", fiber.StatusTeapot)
+			if content != `{"message":"teapot"}` {
+				t.Errorf("unexpected response %s", content)
+			}
+			if len(handledErrors) != 1 || handledErrors[0] != errTeapot {
+				t.Errorf("expected the hook to be called with %s, got %v", errTeapot, handledErrors)
+			}
+		},
+	)
+
+	t.Run(
+		"test patch, head and options",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			patchIUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PatchI(api, patchIUrl, func(input *DataIn, ctx fiber.Ctx) error { return nil }, getName())
+			patchIOUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PatchIO(api, patchIOUrl, fnIO, getName())
+			getUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetO(api, getUrl, fnO, "get with head")
+			// A separate url, so that the HEAD route doesn't replace the documented HEAD route of the GET route.
+			headUrl := getUrl + "/head"
+			swaglay_fiber.Head(api, headUrl, fn, getName())
+			optionsUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.Options(
+				api, optionsUrl, func(ctx fiber.Ctx) error { return ctx.SendStatus(fiber.StatusNoContent) }, getName(),
+			)
+
+			sendRequest(fiberApp, fiber.MethodPatch, patchIUrl, getDataInBodyReader())
+			sendRequest(fiberApp, fiber.MethodPatch, patchIOUrl, getDataInBodyReader())
+			sendRequestExpectedStatus(
+				fiberApp, fiber.MethodPatch, patchIOUrl, fiber.StatusUnprocessableEntity, getInvalidDataInBodyReader(),
+			)
+			sendRequest(fiberApp, fiber.MethodHead, getUrl)
+			sendRequest(fiberApp, fiber.MethodHead, headUrl)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodOptions, optionsUrl, fiber.StatusNoContent)
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			patch := spec.Paths.Value(patchIOUrl).Patch
+			if patch == nil || patch.RequestBody == nil || patch.Responses.Value("200") == nil ||
+				patch.Responses.Value("422") == nil {
+				t.Errorf("expected PATCH %s with a request body, 200 and 422 responses", patchIOUrl)
+			}
+			head := spec.Paths.Value(getUrl).Head
+			if head == nil || head.OperationID != "head-get-with-head" || head.Responses.Value("200").Value.Content != nil {
+				t.Errorf("expected HEAD %s documented without content for the GET route", getUrl)
+			}
+			if spec.Paths.Value(headUrl).Head == nil {
+				t.Errorf("expected HEAD %s", headUrl)
+			}
+			if options := spec.Paths.Value(optionsUrl).Options; options == nil || options.Responses.Value("204") == nil {
+				t.Errorf("expected OPTIONS %s with a 204 response", optionsUrl)
+			}
+
+			registrarApi := swaglay.NewApi("registrar")
+			registrar := swaglay_fiber.NewRegistrar(fiber.New(fiber.Config{DisableHeadAutoRegister: true}), registrarApi)
+			registrar.Get(api, "/without-head", swaglay_fiber.HandleO(fnO), "without head")
+			if route := registrarApi.Routes["/without-head"]; route[http.MethodHead] != nil {
+				t.Errorf("expected no HEAD route when DisableHeadAutoRegister is set")
+			}
+
+			groupApp := fiber.New()
+			swaglay_fiber.NewRegistrar(groupApp.Group("/group"), registrarApi).Get(api, "/with-head", swaglay_fiber.HandleO(fnO), "with head")
+			if route := registrarApi.Routes["/group/with-head"]; route[http.MethodHead] == nil {
+				t.Errorf("expected the HEAD route of a GET route of a group")
+			}
+			sendRequestExpectedStatus(groupApp, fiber.MethodHead, "/group/with-head", fiber.StatusOK)
+
+			disabledApp := fiber.New(fiber.Config{DisableHeadAutoRegister: true})
+			swaglay_fiber.NewRegistrar(disabledApp.Group("/group"), registrarApi, swaglay_fiber.WithApp(disabledApp)).
+				Get(api, "/group-without-head", swaglay_fiber.HandleO(fnO), "group without head")
+			if route := registrarApi.Routes["/group/group-without-head"]; route[http.MethodHead] != nil {
+				t.Errorf("expected no HEAD route of a group when DisableHeadAutoRegister is set")
+			}
+		},
+	)
+
+	t.Run(
+		"test merge patch and json patch",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+			swaglay.Api.RegisterError(
+				swaglay_patch.ErrPatchFailed,
+				rest.ErrorResponse{Status: fiber.StatusUnprocessableEntity, Description: "Patch failed"},
+			)
+
+			type Profile struct {
+				Name  string   `json:"name"`
+				Phone *string  `json:"phone"`
+				Tags  []string `json:"tags"`
+			}
+			type UpdateProfile struct {
+				swaglay_patch.MergePatch
+				Name  rest.Optional[string]  `json:"name,omitzero"`
+				Phone rest.Optional[*string] `json:"phone,omitzero"`
+			}
+			newProfile := func() *Profile {
+				phone := "123"
+				return &Profile{Name: "old", Phone: &phone, Tags: []string{"a"}}
+			}
+
+			mergeUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PatchIO(
+				api, mergeUrl, func(input *UpdateProfile, ctx fiber.Ctx) (*Profile, error) {
+					profile := newProfile()
+					return profile, swaglay_patch.Merge(profile, input)
+				}, getName(),
+			)
+			jsonPatchUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PatchIO(
+				api, jsonPatchUrl, func(input *swaglay_patch.Patch, ctx fiber.Ctx) (*Profile, error) {
+					profile := newProfile()
+					return profile, input.Apply(profile)
+				}, getName(),
+			)
+
+			for _, tc := range []struct {
+				url      string
+				body     string
+				status   int
+				excepted string
+			}{
+				{mergeUrl, `{"phone":null}`, fiber.StatusOK, `{"name":"old","phone":null,"tags":["a"]}`},
+				{mergeUrl, `{"name":"new"}`, fiber.StatusOK, `{"name":"new","phone":"123","tags":["a"]}`},
+				{
+					jsonPatchUrl,
+					`[{"op":"replace","path":"/name","value":"new"},{"op":"add","path":"/tags/-","value":"b"},` +
+						`{"op":"test","path":"/tags/0","value":"a"},{"op":"move","from":"/phone","path":"/tags/0"}]`,
+					fiber.StatusOK,
+					`{"name":"new","phone":null,"tags":["123","a","b"]}`,
+				},
+				{jsonPatchUrl, `[{"op":"test","path":"/name","value":"new"}]`, fiber.StatusUnprocessableEntity, ""},
+				{jsonPatchUrl, `[{"op":"remove","path":"/tags/1"}]`, fiber.StatusUnprocessableEntity, ""},
+			} {
+				content := sendRequestExpectedStatus(fiberApp, fiber.MethodPatch, tc.url, tc.status, strings.NewReader(tc.body))
+				if tc.excepted != "" && content != tc.excepted {
+					t.Errorf("expected %s for %s, got %s", tc.excepted, tc.body, content)
+				}
+			}
+
+			if err := swaglay_patch.Merge(*newProfile(), json.RawMessage(`{}`)); !errors.Is(err, swaglay_patch.ErrPatchFailed) {
+				t.Errorf("expected the merge into a value to fail with %s, got %v", swaglay_patch.ErrPatchFailed, err)
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			mergeContent := spec.Paths.Value(mergeUrl).Patch.RequestBody.Value.Content
+			if mergeContent.Get(swaglay_patch.MergePatchContentType) == nil || mergeContent.Get(swaglay_fiber.JsonContentType) == nil {
+				t.Errorf("expected the merge patch to be documented as JSON and merge patch, got %v", mergeContent)
+			}
+			if spec.Paths.Value(jsonPatchUrl).Patch.RequestBody.Value.Content.Get(swaglay_patch.JSONPatchContentType) == nil {
+				t.Errorf("expected the JSON patch to be documented as %s", swaglay_patch.JSONPatchContentType)
+			}
+
+			updateProfile := spec.Components.Schemas["UpdateProfile"].Value
+			if len(updateProfile.Required) != 0 || !updateProfile.Properties["phone"].Value.Nullable ||
+				!updateProfile.Properties["name"].Value.Type.Is(openapi3.TypeString) {
+				t.Errorf("expected optional nullable fields, got %+v", updateProfile)
+			}
+
+			operation := spec.Components.Schemas["PatchOperation"].Value
+			if excepted := []any{"add", "remove", "replace", "move", "copy", "test"}; !reflect.DeepEqual(
+				operation.Properties["op"].Value.Enum, excepted,
+			) {
+				t.Errorf("expected the JSON patch operations %v", excepted)
+			}
+			if operation.Properties["value"].Value.Type != nil || !reflect.DeepEqual(operation.Required, []string{"op", "path"}) {
+				t.Errorf("expected the JSON patch operation schema, got %+v", operation)
+			}
+		},
+	)
+	t.Run(
+		"test typed path parameters",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			fnPath := func(input *PathIn, ctx fiber.Ctx) (*PathIn, error) { return input, nil }
+
+			bodyUrl := addLeadingSlash(getApiUrl()) + "/{id}/keys/{key}"
+			swaglay_fiber.PutIO(api, bodyUrl, fnPath, getName())
+			queryUrl := addLeadingSlash(getApiUrl()) + "/{id}/keys/{key}"
+			swaglay_fiber.GetIO(api, queryUrl, fnPath, getName())
+
+			key := "6f1c2a3e-5b4d-4c6f-8a9b-0c1d2e3f4a5b"
+			for _, tc := range []struct {
+				method   string
+				url      string
+				body     string
+				status   int
+				excepted string
+			}{
+				{fiber.MethodPut, bodyUrl, `{"name":"test"}`, fiber.StatusOK, `{"ID":7,"Key":"` + key + `","name":"test"}`},
+				{fiber.MethodGet, queryUrl + "?name=test", "", fiber.StatusOK, `{"ID":7,"Key":"` + key + `","name":"test"}`},
+			} {
+				url := strings.NewReplacer("{id}", "7", "{key}", key).Replace(tc.url)
+				content := sendRequestExpectedStatus(fiberApp, tc.method, url, tc.status, strings.NewReader(tc.body))
+				if content != tc.excepted {
+					t.Errorf("expected %s for %s %s, got %s", tc.excepted, tc.method, url, content)
+				}
+			}
+
+			for _, url := range []string{bodyUrl, queryUrl} {
+				method := fiber.MethodGet
+				if url == bodyUrl {
+					method = fiber.MethodPut
+				}
+				for _, tc := range []struct {
+					id, key string
+					status  int
+				}{
+					{"abc", key, fiber.StatusBadRequest},
+					{"7", "not-a-uuid", fiber.StatusBadRequest},
+					{"0", key, fiber.StatusUnprocessableEntity},
+				} {
+					sendRequestExpectedStatus(
+						fiberApp, method, strings.NewReplacer("{id}", tc.id, "{key}", tc.key).Replace(url), tc.status,
+						strings.NewReader(`{"name":"test"}`),
+					)
+				}
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, operation := range []*openapi3.Operation{
+				spec.Paths.Value(bodyUrl).Put, spec.Paths.Value(queryUrl).Get,
+			} {
+				id := operation.Parameters.GetByInAndName(openapi3.ParameterInPath, "id")
+				if id == nil || !id.Schema.Value.Type.Is(openapi3.TypeInteger) || id.Description != "ID of the resource." ||
+					id.Schema.Value.Min == nil || *id.Schema.Value.Min != 1 {
+					t.Errorf("expected the documented id path parameter, got %+v", id)
+				}
+				keyParameter := operation.Parameters.GetByInAndName(openapi3.ParameterInPath, "key")
+				if keyParameter == nil || !keyParameter.Schema.Value.Type.Is(openapi3.TypeString) ||
+					keyParameter.Schema.Value.Format != "uuid" || !keyParameter.Required {
+					t.Errorf("expected the documented key path parameter, got %+v", keyParameter)
+				}
+				for _, name := range []string{"ID", "Key", "id", "key"} {
+					if operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, name) != nil {
+						t.Errorf("expected the path field %s not to be a query parameter", name)
+					}
+				}
+			}
+
+			body := spec.Paths.Value(bodyUrl).Put.RequestBody.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Value
+			if len(body.Properties) != 1 || body.Properties["name"] == nil {
+				t.Errorf("expected only the name in the body, got %v", body.Properties)
+			}
+			// The response is encoded with the path fields.
+			if properties := spec.Components.Schemas["PathIn"].Value.Properties; len(properties) != 3 || properties["ID"] == nil {
+				t.Errorf("expected the path fields in the response, got %v", properties)
+			}
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("expected a panic for a path field that isn't in the url")
+					}
+				}()
+				swaglay_fiber.GetIO(api, addLeadingSlash(getApiUrl())+"/{id}", fnPath, getName())
+			}()
+		},
+	)
+	t.Run(
+		"test header and cookie parameters",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			fnHeader := func(input *HeaderIn, ctx fiber.Ctx) (*HeaderIn, error) { return input, nil }
+
+			bodyUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostIO(api, bodyUrl, fnHeader, getName())
+			queryUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.GetIO(api, queryUrl, fnHeader, getName())
+
+			key := "6f1c2a3e-5b4d-4c6f-8a9b-0c1d2e3f4a5b"
+			headers := map[string]string{
+				"X-Tenant-Id":     "7",
+				"Idempotency-Key": key,
+				"Accept-Language": "fr",
+				"Cookie":          "session=abc",
+			}
+			excepted := `{"TenantID":7,"IdempotencyKey":"` + key + `","Locale":"fr","Session":"abc","name":"test"}`
+			for _, tc := range []struct {
+				name     string
+				headers  map[string]string
+				status   int
+				excepted string
+			}{
+				{"all the parameters", headers, fiber.StatusOK, excepted},
+				{"missing tenant", map[string]string{"X-Tenant-Id": "", "Idempotency-Key": ""}, fiber.StatusUnprocessableEntity, ""},
+				{"invalid tenant", map[string]string{"X-Tenant-Id": "abc"}, fiber.StatusBadRequest, ""},
+				{"invalid idempotency key", map[string]string{"Idempotency-Key": "abc"}, fiber.StatusBadRequest, ""},
+				{"missing session", map[string]string{"Cookie": ""}, fiber.StatusUnprocessableEntity, ""},
+			} {
+				for _, request := range []struct {
+					method, url, body string
+				}{
+					{fiber.MethodPost, bodyUrl, `{"name":"test","TenantID":9,"Session":"body"}`},
+					{fiber.MethodGet, queryUrl + "?name=test&TenantID=9&Session=query", ""},
+				} {
+					req, err := http.NewRequest(request.method, request.url, strings.NewReader(request.body))
+					if err != nil {
+						t.Fatalf("error creating request: %s", err)
+					}
+					for name, value := range headers {
+						req.Header.Set(name, value)
+					}
+					for name, value := range tc.headers {
+						if value == "" {
+							req.Header.Del(name)
+						} else {
+							req.Header.Set(name, value)
+						}
+					}
+
+					response, err := fiberApp.Test(req)
+					if err != nil {
+						t.Fatalf("failed to make request: %s", err)
+					}
+					content, err := io.ReadAll(response.Body)
+					if err != nil {
+						t.Fatalf("failed to read response body: %s", err)
+					}
+					// Successful requests are answered with the status of the method.
+					status := tc.status
+					if status == fiber.StatusOK {
+						status = swaglay.SuccessStatus(request.method, true)
+					}
+					if response.StatusCode != status {
+						t.Errorf("expected status code %d for %s %s, got %d content %s", status, tc.name, request.method, response.StatusCode, content)
+					}
+					if tc.excepted != "" && string(content) != tc.excepted {
+						t.Errorf("expected %s for %s %s, got %s", tc.excepted, tc.name, request.method, content)
+					}
+				}
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, operation := range []*openapi3.Operation{spec.Paths.Value(bodyUrl).Post, spec.Paths.Value(queryUrl).Get} {
+				tenant := operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "X-Tenant-Id")
+				if tenant == nil || !tenant.Required || !tenant.Schema.Value.Type.Is(openapi3.TypeInteger) ||
+					tenant.Description != "Tenant of the request." || tenant.Schema.Value.Min == nil || *tenant.Schema.Value.Min != 1 {
+					t.Errorf("expected the documented tenant header, got %+v", tenant)
+				}
+				idempotencyKey := operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "Idempotency-Key")
+				if idempotencyKey == nil || idempotencyKey.Required || idempotencyKey.Schema.Value.Format != "uuid" {
+					t.Errorf("expected the documented idempotency key header, got %+v", idempotencyKey)
+				}
+				if operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "Accept-Language") == nil {
+					t.Errorf("expected the documented locale header")
+				}
+				session := operation.Parameters.GetByInAndName(openapi3.ParameterInCookie, "session")
+				if session == nil || !session.Required || session.Description != "Session of the user." {
+					t.Errorf("expected the documented session cookie, got %+v", session)
+				}
+				for _, name := range []string{"TenantID", "Session", "Locale"} {
+					if operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, name) != nil {
+						t.Errorf("expected the field %s not to be a query parameter", name)
+					}
+				}
+			}
+
+			body := spec.Paths.Value(bodyUrl).Post.RequestBody.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Value
+			if len(body.Properties) != 1 || body.Properties["name"] == nil {
+				t.Errorf("expected only the name in the body, got %v", body.Properties)
+			}
+			// The response is encoded with the header and cookie fields.
+			if properties := spec.Components.Schemas["HeaderIn"].Value.Properties; len(properties) != 5 || properties["Session"] == nil {
+				t.Errorf("expected the header and cookie fields in the response, got %v", properties)
+			}
+		},
+	)
+	t.Run(
+		"test composite input",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			fnComposite := func(input *CompositeIn, ctx fiber.Ctx) (*CompositeIn, error) { return input, nil }
+
+			url := addLeadingSlash(getApiUrl()) + "/orgs/{orgId}/users"
+			swaglay_fiber.PostIO(api, url, fnComposite, getName())
+			swaglay_fiber.DeleteIO(api, url, fnComposite, getName())
+
+			for _, method := range []string{fiber.MethodPost, fiber.MethodDelete} {
+				for _, tc := range []struct {
+					query    string
+					body     string
+					status   int
+					excepted string
+				}{
+					{"?notify=true", `{"name":"test"}`, fiber.StatusOK, `{"Body":{"name":"test"},"OrgID":3,"Notify":true,"TenantID":7}`},
+					{"", `{"name":"test"}`, fiber.StatusOK, `{"Body":{"name":"test"},"OrgID":3,"Notify":null,"TenantID":7}`},
+					{"?notify=abc", `{"name":"test"}`, fiber.StatusBadRequest, ""},
+					{"?notify=true", `{"name":`, fiber.StatusUnprocessableEntity, ""},
+					{"?notify=true", `{}`, fiber.StatusUnprocessableEntity, ""},
+					{"?notify=true", "", fiber.StatusUnprocessableEntity, ""},
+				} {
+					req, err := http.NewRequest(method, strings.Replace(url, "{orgId}", "3", 1)+tc.query, strings.NewReader(tc.body))
+					if err != nil {
+						t.Fatalf("error creating request: %s", err)
+					}
+					req.Header.Set("X-Tenant-Id", "7")
+
+					response, err := fiberApp.Test(req)
+					if err != nil {
+						t.Fatalf("failed to make request: %s", err)
+					}
+					content, err := io.ReadAll(response.Body)
+					if err != nil {
+						t.Fatalf("failed to read response body: %s", err)
+					}
+					// Successful requests are answered with the status of the method.
+					status := tc.status
+					if status == fiber.StatusOK {
+						status = swaglay.SuccessStatus(method, true)
+					}
+					if response.StatusCode != status {
+						t.Errorf("expected status code %d for %s %s %s, got %d content %s", status, method, tc.query, tc.body, response.StatusCode, content)
+					}
+					if tc.excepted != "" && string(content) != tc.excepted {
+						t.Errorf("expected %s for %s %s %s, got %s", tc.excepted, method, tc.query, tc.body, content)
+					}
+				}
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			path := spec.Paths.Value(url)
+			for _, operation := range []*openapi3.Operation{path.Post, path.Delete} {
+				requestBody := operation.RequestBody.Value.Content.Get(swaglay_fiber.JsonContentType)
+				if requestBody == nil || requestBody.Schema.Ref != "#/components/schemas/CreateOrgUser" {
+					t.Errorf("expected the body to be documented as CreateOrgUser, got %+v", requestBody)
+				}
+				notify := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "notify")
+				if notify == nil || notify.Required || !notify.Schema.Value.Type.Is(openapi3.TypeBoolean) ||
+					notify.Description != "Notify the user by email." {
+					t.Errorf("expected the documented notify query parameter, got %+v", notify)
+				}
+				orgId := operation.Parameters.GetByInAndName(openapi3.ParameterInPath, "orgId")
+				if orgId == nil || !orgId.Schema.Value.Type.Is(openapi3.TypeInteger) {
+					t.Errorf("expected the documented orgId path parameter, got %+v", orgId)
+				}
+				if operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "X-Tenant-Id") == nil {
+					t.Errorf("expected the documented tenant header")
+				}
+				if len(operation.Parameters) != 3 {
+					t.Errorf("expected only the tagged fields as parameters, got %d", len(operation.Parameters))
+				}
+			}
+		},
+	)
+	t.Run(
+		"test form uploads",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			type Uploaded struct {
+				Filename    string   `json:"filename"`
+				Size        int64    `json:"size"`
+				ContentType string   `json:"contentType"`
+				Alt         string   `json:"alt"`
+				Tags        []string `json:"tags"`
+			}
+
+			uploadUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostIO(
+				api, uploadUrl, func(input *UploadAvatar, ctx fiber.Ctx) (*Uploaded, error) {
+					return &Uploaded{
+						Filename:    input.Avatar.Filename(),
+						Size:        input.Avatar.Size(),
+						ContentType: input.Avatar.ContentType(),
+						Alt:         input.Alt,
+						Tags:        input.Tags,
+					}, nil
+				}, getName(),
+			)
+			subscribeUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostIO(
+				api, subscribeUrl, func(input *Subscribe, ctx fiber.Ctx) (*Subscribe, error) {
+					return input, nil
+				}, getName(),
+			)
+
+			send := func(url, contentType string, body io.Reader) (int, string) {
+				req, err := http.NewRequest(fiber.MethodPost, url, body)
+				if err != nil {
+					t.Fatalf("error creating request: %s", err)
+				}
+				req.Header.Set(fiber.HeaderContentType, contentType)
+				response, err := fiberApp.Test(req)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				content, err := io.ReadAll(response.Body)
+				if err != nil {
+					t.Fatalf("failed to read response body: %s", err)
+				}
+				return response.StatusCode, string(content)
+			}
+			newUpload := func(fileContentType string, file []byte, alt string) (string, io.Reader) {
+				body := &bytes.Buffer{}
+				writer := multipart.NewWriter(body)
+				header := make(textproto.MIMEHeader)
+				header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+				header.Set("Content-Type", fileContentType)
+				part, err := writer.CreatePart(header)
+				if err != nil {
+					t.Fatalf("failed to create part: %s", err)
+				}
+				if _, err = part.Write(file); err != nil {
+					t.Fatalf("failed to write part: %s", err)
+				}
+				if alt != "" {
+					_ = writer.WriteField("alt", alt)
+				}
+				_ = writer.WriteField("tags", "a")
+				_ = writer.WriteField("tags", "b")
+				if err = writer.Close(); err != nil {
+					t.Fatalf("failed to close writer: %s", err)
+				}
+				return writer.FormDataContentType(), body
+			}
+
+			newFile := func(signature string, size int) []byte {
+				return append([]byte(signature), bytes.Repeat([]byte{1}, size-len(signature))...)
+			}
+			const png, jpeg = "\x89PNG\r\n\x1a\n", "\xff\xd8\xff"
+
+			for _, tc := range []struct {
+				name            string
+				fileContentType string
+				file            []byte
+				alt             string
+				status          int
+				excepted        string
+			}{
+				{"valid", "image/png", newFile(png, 10), "me", fiber.StatusCreated, `{"filename":"avatar.png","size":10,"contentType":"image/png","alt":"me","tags":["a","b"]}`},
+				{"too large", "image/png", newFile(png, 2000), "me", fiber.StatusRequestEntityTooLarge, ""},
+				{"too large form", "image/png", newFile(png, 100<<10), "me", fiber.StatusRequestEntityTooLarge, ""},
+				{"unsupported type", "text/plain", []byte("plain text"), "me", fiber.StatusUnsupportedMediaType, ""},
+				{"spoofed type", "image/png", []byte("plain text"), "me", fiber.StatusUnsupportedMediaType, ""},
+				{"missing alt", "image/jpeg", newFile(jpeg, 10), "", fiber.StatusUnprocessableEntity, ""},
+			} {
+				contentType, body := newUpload(tc.fileContentType, tc.file, tc.alt)
+				status, content := send(uploadUrl, contentType, body)
+				if status != tc.status {
+					t.Errorf("expected status code %d for %s, got %d content %s", tc.status, tc.name, status, content)
+				}
+				if tc.excepted != "" && content != tc.excepted {
+					t.Errorf("expected %s for %s, got %s", tc.excepted, tc.name, content)
+				}
+			}
+			if status, _ := send(uploadUrl, swaglay_fiber.JsonContentType, strings.NewReader(`{"alt":"me"}`)); status != fiber.StatusUnsupportedMediaType {
+				t.Errorf("expected status code %d for a JSON body, got %d", fiber.StatusUnsupportedMediaType, status)
+			}
+
+			status, content := send(subscribeUrl, rest.URLEncodedFormContentType, strings.NewReader("email=a%40b.c&count=3"))
+			if status != fiber.StatusCreated || content != `{"Email":"a@b.c","Count":3}` {
+				t.Errorf("expected the bound form, got %d %s", status, content)
+			}
+			if status, _ = send(subscribeUrl, rest.URLEncodedFormContentType, strings.NewReader("email=a%40b.c&count=abc")); status != fiber.StatusBadRequest {
+				t.Errorf("expected status code %d for an invalid count, got %d", fiber.StatusBadRequest, status)
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			uploadContent := spec.Paths.Value(uploadUrl).Post.RequestBody.Value.Content
+			if len(uploadContent) != 1 || uploadContent.Get(rest.MultipartFormContentType) == nil {
+				t.Fatalf("expected a multipart body, got %v", uploadContent)
+			}
+			if encoding := uploadContent.Get(rest.MultipartFormContentType).Encoding["avatar"]; encoding == nil ||
+				encoding.ContentType != "image/png, image/jpeg" {
+				t.Errorf("expected the accepted types of the avatar, got %+v", encoding)
+			}
+			avatar := uploadContent.Get(rest.MultipartFormContentType).Schema.Value.Properties["avatar"].Value
+			if !avatar.Type.Is(openapi3.TypeString) || avatar.Format != "binary" || avatar.MaxLength == nil || *avatar.MaxLength != 1024 {
+				t.Errorf("expected a binary avatar of at most 1024 bytes, got %+v", avatar)
+			}
+
+			subscribeContent := spec.Paths.Value(subscribeUrl).Post.RequestBody.Value.Content
+			if len(subscribeContent) != 2 || subscribeContent.Get(rest.URLEncodedFormContentType) == nil ||
+				subscribeContent.Get(rest.MultipartFormContentType) == nil {
+				t.Errorf("expected multipart and urlencoded bodies, got %v", subscribeContent)
+			}
+			if properties := subscribeContent.Get(rest.URLEncodedFormContentType).Schema.Value.Properties; properties["email"] == nil || properties["count"] == nil {
+				t.Errorf("expected the form fields to be named by their tags, got %v", properties)
+			}
+			// The response is encoded as JSON, so its fields aren't named by their form tags.
+			if properties := spec.Components.Schemas["Subscribe"].Value.Properties; properties["Email"] == nil || properties["Count"] == nil {
+				t.Errorf("expected the response fields to be named as they're encoded, got %v", properties)
+			}
+		},
+	)
+	t.Run(
+		"test response status",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			createdUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostO(
+				api, createdUrl, func(ctx fiber.Ctx) (swaglay.Result[*DataOut], error) {
+					return swaglay.NewResult(0, &DataOut{Name: "test"}).WithHeader("Location", "/test"), nil
+				}, getName(),
+			)
+			acceptedUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PutO(
+				api, acceptedUrl, func(ctx fiber.Ctx) (swaglay.Result[*DataOut], error) {
+					return swaglay.NewResult(fiber.StatusAccepted, &DataOut{Name: "test"}), nil
+				}, getName(),
+			)
+			noContentUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PutO(
+				api, noContentUrl, func(ctx fiber.Ctx) (swaglay.Result[*DataOut], error) {
+					return swaglay.NoContent[*DataOut](), nil
+				}, getName(),
+			)
+			deleteUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.Delete(api, deleteUrl, func(ctx fiber.Ctx) error { return nil }, getName())
+			deleteOUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.DeleteO(
+				api, deleteOUrl, func(ctx fiber.Ctx) (*DataOut, error) {
+					return &DataOut{Name: "test"}, nil
+				}, getName(),
+			)
+
+			for _, tc := range []struct {
+				method   string
+				url      string
+				status   int
+				excepted string
+				location string
+			}{
+				{fiber.MethodPost, createdUrl, fiber.StatusCreated, `{"name":"test"}`, "/test"},
+				{fiber.MethodPut, acceptedUrl, fiber.StatusAccepted, `{"name":"test"}`, ""},
+				{fiber.MethodPut, noContentUrl, fiber.StatusNoContent, "", ""},
+				{fiber.MethodDelete, deleteUrl, fiber.StatusNoContent, "", ""},
+				{fiber.MethodDelete, deleteOUrl, fiber.StatusOK, `{"name":"test"}`, ""},
+			} {
+				request, err := http.NewRequest(tc.method, tc.url, nil)
+				if err != nil {
+					t.Fatalf("error creating request: %s", err)
+				}
+				response, err := fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				content, err := io.ReadAll(response.Body)
+				if err != nil {
+					t.Fatalf("failed to read response body: %s", err)
+				}
+				if response.StatusCode != tc.status || string(content) != tc.excepted {
+					t.Errorf("expected %d %s for %s %s, got %d %s", tc.status, tc.excepted, tc.method, tc.url, response.StatusCode, content)
+				}
+				if location := response.Header.Get("Location"); location != tc.location {
+					t.Errorf("expected the location %q for %s %s, got %q", tc.location, tc.method, tc.url, location)
+				}
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			created := spec.Paths.Value(createdUrl).Post.Responses.Value("201")
+			if created == nil || created.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Ref != "#/components/schemas/DataOut" {
+				t.Errorf("expected the body of the result to be documented, got %+v", created)
+			}
+			if deleted := spec.Paths.Value(deleteUrl).Delete.Responses.Value("204"); deleted == nil || deleted.Value.Content != nil {
+				t.Errorf("expected a 204 response without content, got %+v", deleted)
+			}
+			if spec.Paths.Value(deleteOUrl).Delete.Responses.Value("200") == nil {
+				t.Errorf("expected a 200 response for a DELETE route with output")
+			}
+		},
+	)
+	t.Run(
+		"test multi-status responses",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			var responses *OrderResponses
+			ordersUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.PostO(
+				api, ordersUrl, func(ctx fiber.Ctx) (*OrderResponses, error) {
+					return responses, nil
+				}, getName(),
+			)
+
+			for _, tc := range []struct {
+				responses *OrderResponses
+				status    int
+				excepted  string
+			}{
+				{&OrderResponses{OK: &Order{ID: "1"}}, fiber.StatusOK, `{"id":"1"}`},
+				{&OrderResponses{Accepted: &Job{ID: "1"}}, fiber.StatusAccepted, `{"id":"1"}`},
+				{&OrderResponses{Conflict: &Conflict{Reason: "paid"}}, fiber.StatusConflict, `{"reason":"paid"}`},
+				{&OrderResponses{NotFound: &dtos.NotFound{}}, fiber.StatusNotFound, `{}`},
+			} {
+				responses = tc.responses
+				if content := sendRequestExpectedStatus(fiberApp, fiber.MethodPost, ordersUrl, tc.status); content != tc.excepted {
+					t.Errorf("expected %s for the status %d, got %s", tc.excepted, tc.status, content)
+				}
+			}
+
+			for _, invalid := range []*OrderResponses{nil, {}, {OK: &Order{}, Accepted: &Job{}}} {
+				responses = invalid
+				sendRequestExpectedStatus(fiberApp, fiber.MethodPost, ordersUrl, fiber.StatusInternalServerError)
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			operationResponses := spec.Paths.Value(ordersUrl).Post.Responses
+			if operationResponses.Value("201") != nil {
+				t.Errorf("expected the declared responses instead of the 201 response")
+			}
+			for status, model := range map[string]string{"200": "Order", "202": "Job", "409": "Conflict", "404": "NotFound"} {
+				response := operationResponses.Value(status)
+				if response == nil || response.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Ref != "#/components/schemas/"+model {
+					t.Errorf("expected the %s response to be documented as %s, got %+v", status, model, response)
+				}
+			}
+		},
+	)
+	t.Run(
+		"test registered errors",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			errorsApi := swaglay.NewApi("errors")
+			errorsApi.RegisterError(ErrOrderNotFound, rest.ErrorResponse{Status: fiber.StatusNotFound, Description: "Order not found"})
+			errorsApi.RegisterError(
+				context.DeadlineExceeded,
+				rest.ErrorResponse{Status: fiber.StatusServiceUnavailable, Description: "Try again", Body: &Conflict{Reason: "busy"}},
+			)
+			rest.RegisterErrorType[*ConflictError](errorsApi, rest.ErrorResponse{Status: fiber.StatusConflict, Description: "Conflict"})
+			errorsApi.RegisterError(
+				context.Canceled,
+				rest.ErrorResponse{Status: fiber.StatusLocked, Description: "Locked", Methods: []string{fiber.MethodPost}},
+			)
+
+			var handlerErr error
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, errorsApi)
+			ordersUrl := addLeadingSlash(getApiUrl())
+			registrar.Get(api, ordersUrl, swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*Order, error) {
+				return nil, handlerErr
+			}), getName())
+			declaredUrl := addLeadingSlash(getApiUrl())
+			registrar.Get(api, declaredUrl, swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*Order, error) {
+				return nil, handlerErr
+			}), getName())
+			errorsApi.Get(declaredUrl).HasResponseModel(fiber.StatusNotFound, rest.ModelOf[Conflict]())
+
+			for _, tc := range []struct {
+				err      error
+				status   int
+				excepted string
+			}{
+				{fmt.Errorf("get order: %w", ErrOrderNotFound), fiber.StatusNotFound, `{"error":"get order: order not found"}`},
+				{fmt.Errorf("get order: %w", &ConflictError{Resource: "order"}), fiber.StatusConflict, `{"resource":"order"}`},
+				{context.DeadlineExceeded, fiber.StatusServiceUnavailable, `{"reason":"busy"}`},
+				{errors.New("unexpected"), fiber.StatusInternalServerError, `{"error":"unexpected"}`},
+			} {
+				handlerErr = tc.err
+				if content := sendRequestExpectedStatus(fiberApp, fiber.MethodGet, ordersUrl, tc.status); content != tc.excepted {
+					t.Errorf("expected %s for %s, got %s", tc.excepted, tc.err, content)
+				}
+			}
+
+			spec, err := errorsApi.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			responses := spec.Paths.Value(ordersUrl).Get.Responses
+			notFound := responses.Value("404")
+			if notFound == nil || *notFound.Value.Description != "Order not found" || notFound.Value.Content != nil {
+				t.Errorf("expected the registered 404 response to replace the generic one, got %+v", notFound)
+			}
+			declared := spec.Paths.Value(declaredUrl).Get.Responses.Value("404")
+			if declared == nil || declared.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Ref != "#/components/schemas/Conflict" {
+				t.Errorf("expected the declared 404 response not to be overwritten, got %+v", declared)
+			}
+			if responses.Value("423") != nil {
+				t.Errorf("expected the 423 response to be documented in POST operations only")
+			}
+			if head := spec.Paths.Value(ordersUrl).Head; head == nil || head.Responses.Value("409") != nil {
+				t.Errorf("expected the HEAD operation without the registered errors, got %+v", head)
+			}
+			conflict := responses.Value("409")
+			if conflict == nil || *conflict.Value.Description != "Conflict" ||
+				conflict.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Ref != "#/components/schemas/ConflictError" {
+				t.Errorf("expected the 409 response to be documented by the error type, got %+v", conflict)
+			}
+			unavailable := responses.Value("503")
+			if unavailable == nil || unavailable.Value.Content.Get(swaglay_fiber.JsonContentType).Schema.Ref != "#/components/schemas/Conflict" {
+				t.Errorf("expected the 503 response to be documented by its body, got %+v", unavailable)
+			}
+		},
+	)
+	t.Run(
+		"test problem details",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			problemsApi := swaglay.NewApi("problems", rest.WithProblemDetails())
+			rest.RegisterErrorType[*ConflictError](
+				problemsApi,
+				rest.ErrorResponse{Status: fiber.StatusConflict, Description: "Conflict", Type: "https://example.com/problems/conflict"},
+			)
+
+			var handlerErr error
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, problemsApi)
+			ordersUrl := addLeadingSlash(getApiUrl())
+			registrar.Get(api, ordersUrl, swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*Order, error) {
+				return nil, handlerErr
+			}), getName())
+			createUrl := addLeadingSlash(getApiUrl())
+			registrar.Post(api, createUrl, swaglay_fiber.HandleI(func(input *DataIn, ctx fiber.Ctx) error {
+				return nil
+			}), getName())
+
+			send := func(method, url string, status int, body io.Reader) map[string]any {
+				request, err := http.NewRequest(method, url, body)
+				if err != nil {
+					t.Fatalf("error creating request: %s", err)
+				}
+				request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				response, err := fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				if response.StatusCode != status {
+					t.Fatalf("expected status code %d, got %d", status, response.StatusCode)
+				}
+				if contentType := response.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, rest.ProblemJSONContentType) {
+					t.Errorf("expected the content type %s, got %s", rest.ProblemJSONContentType, contentType)
+				}
+				var problem map[string]any
+				if err = json.NewDecoder(response.Body).Decode(&problem); err != nil {
+					t.Fatalf("failed to decode the problem details: %s", err)
+				}
+				return problem
+			}
+
+			handlerErr = &ConflictError{Resource: "order"}
+			problem := send(fiber.MethodGet, ordersUrl, fiber.StatusConflict, nil)
+			excepted := map[string]any{
+				"type":     "https://example.com/problems/conflict",
+				"title":    "Conflict",
+				"status":   float64(fiber.StatusConflict),
+				"detail":   "order conflicts",
+				"instance": ordersUrl,
+				"resource": "order",
+			}
+			if !reflect.DeepEqual(problem, excepted) {
+				t.Errorf("expected %v, got %v", excepted, problem)
+			}
+
+			handlerErr = errors.New("unexpected")
+			problem = send(fiber.MethodGet, ordersUrl, fiber.StatusInternalServerError, nil)
+			if problem["status"] != float64(fiber.StatusInternalServerError) || problem["title"] != "Internal Server Error" ||
+				problem["type"] != "about:blank" || problem["detail"] != "unexpected" {
+				t.Errorf("expected the problem details of a 500 response, got %v", problem)
+			}
+
+			problem = send(fiber.MethodPost, createUrl, fiber.StatusUnprocessableEntity, strings.NewReader(`{"name":"ttt"}`))
+			if problem["status"] != float64(fiber.StatusUnprocessableEntity) || problem["instance"] != createUrl {
+				t.Errorf("expected the problem details of a 422 response, got %v", problem)
+			}
+
+			spec, err := problemsApi.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			if spec.Components.Schemas["ProblemDetails"] == nil {
+				t.Fatalf("expected the ProblemDetails schema to be registered")
+			}
+
+			const problemRef = "#/components/schemas/ProblemDetails"
+			notFound := spec.Paths.Value(ordersUrl).Get.Responses.Value("404").Value.Content.Get(rest.ProblemJSONContentType)
+			if notFound == nil || notFound.Schema.Ref != problemRef {
+				t.Errorf("expected the 404 response to reference the problem details, got %+v", notFound)
+			}
+			unprocessable := spec.Paths.Value(createUrl).Post.Responses.Value("422").Value.Content.Get(rest.ProblemJSONContentType)
+			if unprocessable == nil || len(unprocessable.Schema.Value.AllOf) != 2 || unprocessable.Schema.Value.AllOf[0].Ref != problemRef ||
+				unprocessable.Schema.Value.AllOf[1].Ref != "#/components/schemas/UnprocessableEntity" {
+				t.Errorf("expected the 422 response to extend the problem details with the fields, got %+v", unprocessable)
+			}
+			conflict := spec.Paths.Value(ordersUrl).Get.Responses.Value("409").Value.Content.Get(rest.ProblemJSONContentType)
+			if conflict == nil || len(conflict.Schema.Value.AllOf) != 2 || conflict.Schema.Value.AllOf[0].Ref != problemRef ||
+				conflict.Schema.Value.AllOf[1].Ref != "#/components/schemas/ConflictError" {
+				t.Errorf("expected the 409 response to extend the problem details with the error, got %+v", conflict)
+			}
+			if ok := spec.Paths.Value(ordersUrl).Get.Responses.Value("200").Value.Content.Get(swaglay_fiber.JsonContentType); ok == nil {
+				t.Errorf("expected the success response not to be a problem")
+			}
+
+			withErrors := rest.NewProblemDetails(fiber.StatusBadRequest, "").WithExtensions([]string{"a", "b"})
+			if errs := withErrors.Extensions[rest.ProblemErrorsMember]; !reflect.DeepEqual(errs, []any{"a", "b"}) {
+				t.Errorf("expected a slice to be the errors member, got %v", withErrors.Extensions)
+			}
+			problemsApi.RegisterError(
+				context.Canceled,
+				rest.ErrorResponse{Status: fiber.StatusLocked, Description: "Locked", Body: []Conflict{{Reason: "busy"}}},
+			)
+			if spec, err = problemsApi.Spec(); err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			locked := spec.Paths.Value(ordersUrl).Get.Responses.Value("423").Value.Content.Get(rest.ProblemJSONContentType)
+			if locked == nil || len(locked.Schema.Value.AllOf) != 2 ||
+				locked.Schema.Value.AllOf[1].Value.Properties[rest.ProblemErrorsMember] == nil {
+				t.Errorf("expected the 423 response to document the slice as the errors member, got %+v", locked)
+			}
+		},
+	)
+	t.Run(
+		"test validation error fields",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+
+			validatorEngine := validator.New()
+			validatorEngine.SetTagName("binding")
+			english := en.New()
+			translator := ut.New(english, english, fr.New())
+			englishTrans, _ := translator.GetTranslator("en")
+			if err := en_translations.RegisterDefaultTranslations(validatorEngine, englishTrans); err != nil {
+				t.Fatalf("failed to register the translations: %s", err)
+			}
+			frenchTrans, _ := translator.GetTranslator("fr")
+			if err := fr_translations.RegisterDefaultTranslations(validatorEngine, frenchTrans); err != nil {
+				t.Fatalf("failed to register the translations: %s", err)
+			}
+			fiberApp := fiber.New(fiber.Config{StructValidator: &AppValidator{OriginalValidate: validatorEngine}})
+
+			type Line struct {
+				Sku string `json:"sku" binding:"required"`
+			}
+			type CreateOrder struct {
+				Customer string `json:"customer" binding:"min=3"`
+				Lines    []Line `json:"lines" binding:"dive"`
+			}
+			type Filter struct {
+				Name string `json:"name" binding:"max=2"`
+			}
+			type SearchOrders struct {
+				Filter Filter `json:"filter"`
+			}
+
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, swaglay.Api, swaglay_fiber.WithTranslator(translator))
+			createUrl := addLeadingSlash(getApiUrl())
+			registrar.Post(api, createUrl, swaglay_fiber.HandleI(func(input *CreateOrder, ctx fiber.Ctx) error {
+				return nil
+			}), getName())
+			searchUrl := addLeadingSlash(getApiUrl())
+			registrar.Get(api, searchUrl, swaglay_fiber.HandleI(func(input *SearchOrders, ctx fiber.Ctx) error {
+				return nil
+			}), getName())
+
+			send := func(method, url, language string, body io.Reader) dtos.UnprocessableEntity {
+				request, err := http.NewRequest(method, url, body)
+				if err != nil {
+					t.Fatalf("error creating request: %s", err)
+				}
+				request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				request.Header.Set(fiber.HeaderAcceptLanguage, language)
+				response, err := fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				if response.StatusCode != fiber.StatusUnprocessableEntity {
+					t.Fatalf("expected status code %d, got %d", fiber.StatusUnprocessableEntity, response.StatusCode)
+				}
+				var payload dtos.UnprocessableEntity
+				if err = json.NewDecoder(response.Body).Decode(&payload); err != nil {
+					t.Fatalf("failed to decode the validation errors: %s", err)
+				}
+				return payload
+			}
+
+			payload := send(fiber.MethodPost, createUrl, "de;q=1, fr-CA;q=0.9, en;q=0.5", strings.NewReader(`{"customer":"ab","lines":[{"sku":"a"},{}]}`))
+			excepted := []dtos.FieldError{
+				{Field: "customer", Tag: "min", Param: "3", Message: "Customer doit faire une taille minimum de 3 caractères"},
+				{Field: "lines[1].sku", Tag: "required", Message: "Sku est un champ obligatoire"},
+			}
+			if !reflect.DeepEqual(payload.Fields, excepted) {
+				t.Errorf("expected %+v, got %+v", excepted, payload.Fields)
+			}
+
+			payload = send(fiber.MethodGet, searchUrl+"?filter[name]=abc", "", nil)
+			excepted = []dtos.FieldError{{Field: "filter[name]", Tag: "max", Param: "2", Message: "Name must be a maximum of 2 characters in length"}}
+			if !reflect.DeepEqual(payload.Fields, excepted) {
+				t.Errorf("expected %+v, got %+v", excepted, payload.Fields)
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			unprocessable := spec.Paths.Value(createUrl).Post.Responses.Value("422").Value.Content.Get(swaglay_fiber.JsonContentType)
+			if unprocessable == nil || unprocessable.Schema.Ref != "#/components/schemas/UnprocessableEntity" {
+				t.Fatalf("expected the 422 response to be documented as UnprocessableEntity, got %+v", unprocessable)
+			}
+			if fields := spec.Components.Schemas["UnprocessableEntity"].Value.Properties["fields"]; fields == nil || fields.Value.Items.Ref != "#/components/schemas/FieldError" {
+				t.Errorf("expected the fields of the validation errors to be documented, got %+v", fields)
+			}
+		},
+	)
+	t.Run(
+		"test request validation",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			// The rules of the validate tag are documented, but the struct validator of the app reads the binding tag.
+			type RenameOrder struct {
+				Name string `json:"name" validate:"required,min=3"`
+			}
+			type RenameOrderIn struct {
+				Body     RenameOrder `body:""`
+				ID       int         `path:"id"`
+				TenantID int         `header:"X-Tenant-Id" validate:"required"`
+				Limit    *int        `query:"limit" validate:"max=10"`
+			}
+
+			validatedApi := swaglay.NewApi("validated")
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, validatedApi, swaglay_fiber.WithRequestValidation())
+			ordersUrl := addLeadingSlash(getApiUrl()) + "/{id}"
+			registrar.Put(api, ordersUrl, swaglay_fiber.HandleIO(func(input *RenameOrderIn, ctx fiber.Ctx) (*Order, error) {
+				return &Order{ID: strconv.Itoa(input.ID)}, nil
+			}), getName())
+			unvalidatedUrl := addLeadingSlash(getApiUrl()) + "/{id}"
+			swaglay_fiber.NewRegistrar(fiberApp, validatedApi).Put(api, unvalidatedUrl, swaglay_fiber.HandleIO(func(input *RenameOrderIn, ctx fiber.Ctx) (*Order, error) {
+				return &Order{ID: strconv.Itoa(input.ID)}, nil
+			}), getName())
+
+			for _, tc := range []struct {
+				name   string
+				url    string
+				id     string
+				query  string
+				tenant string
+				body   string
+				status int
+			}{
+				{"valid", ordersUrl, "1", "?limit=5", "1", `{"name":"abc"}`, fiber.StatusOK},
+				{"invalid path parameter", ordersUrl, "abc", "", "1", `{"name":"abc"}`, fiber.StatusBadRequest},
+				{"invalid query parameter", ordersUrl, "1", "?limit=11", "1", `{"name":"abc"}`, fiber.StatusBadRequest},
+				{"missing header", ordersUrl, "1", "", "", `{"name":"abc"}`, fiber.StatusBadRequest},
+				{"invalid body", ordersUrl, "1", "", "1", `{"name":"ab"}`, fiber.StatusUnprocessableEntity},
+				{"without request validation", unvalidatedUrl, "1", "?limit=11", "", `{"name":"ab"}`, fiber.StatusOK},
+			} {
+				t.Run(tc.name, func(t *testing.T) {
+					url := strings.Replace(tc.url, "{id}", tc.id, 1) + tc.query
+					request, err := http.NewRequest(fiber.MethodPut, url, strings.NewReader(tc.body))
+					if err != nil {
+						t.Fatalf("error creating request: %s", err)
+					}
+					request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+					if tc.tenant != "" {
+						request.Header.Set("X-Tenant-Id", tc.tenant)
+					}
+					response, err := fiberApp.Test(request)
+					if err != nil {
+						t.Fatalf("failed to make request: %s", err)
+					}
+					content, _ := io.ReadAll(response.Body)
+					if response.StatusCode != tc.status {
+						t.Errorf("expected status code %d, got %d %s", tc.status, response.StatusCode, content)
+					}
+				})
+			}
+		},
+	)
+
+	t.Run(
+		"test request validation of merge patches",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			type UpdateOrder struct {
+				swaglay_patch.MergePatch
+				Name rest.Optional[string] `json:"name,omitzero"`
+			}
+
+			validatedApi := swaglay.NewApi("validated")
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, validatedApi, swaglay_fiber.WithRequestValidation())
+			url := addLeadingSlash(getApiUrl())
+			registrar.Patch(api, url, swaglay_fiber.HandleIO(func(input *UpdateOrder, ctx fiber.Ctx) (*Order, error) {
+				return &Order{ID: input.Name.Value}, nil
+			}), getName())
+
+			for _, tc := range []struct {
+				contentType string
+				body        string
+				status      int
+			}{
+				{swaglay_patch.MergePatchContentType, `{"name":"abc"}`, fiber.StatusOK},
+				{swaglay_patch.MergePatchContentType, `{"name":1}`, fiber.StatusUnprocessableEntity},
+				{fiber.MIMEApplicationJSON, `{"name":"abc"}`, fiber.StatusOK},
+			} {
+				request, err := http.NewRequest(fiber.MethodPatch, url, strings.NewReader(tc.body))
+				if err != nil {
+					t.Fatalf("error creating request: %s", err)
+				}
+				request.Header.Set(fiber.HeaderContentType, tc.contentType)
+				response, err := fiberApp.Test(request)
+				if err != nil {
+					t.Fatalf("failed to make request: %s", err)
+				}
+				content, _ := io.ReadAll(response.Body)
+				if response.StatusCode != tc.status {
+					t.Errorf("expected status code %d for %s %s, got %d %s", tc.status, tc.contentType, tc.body, response.StatusCode, content)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test query input without a struct validator",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := fiber.New()
+
+			url := addLeadingSlash(getApiUrl())
+			swaglay_fiber.NewRegistrar(fiberApp, swaglay.Api).Get(api, url, swaglay_fiber.HandleIO(fnIO), getName())
+
+			sendRequestExpectedStatus(fiberApp, fiber.MethodGet, url+getInvalidDataInQueryString(), fiber.StatusOK)
+		},
+	)
+
+	t.Run(
+		"test delete input documented as query parameters",
+		func(t *testing.T) {
+			swaglay.SetupApi(api, rest.WithValidateTagName("binding"))
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			url := addLeadingSlash(getApiUrl())
+			swaglay_fiber.DeleteI(api, url, func(input *DataIn, ctx fiber.Ctx) error { return nil }, getName())
+
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, url+getDataInQueryString(), fiber.StatusNoContent)
+			sendRequestExpectedStatus(fiberApp, fiber.MethodDelete, url+getInvalidDataInQueryString(), fiber.StatusUnprocessableEntity)
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			operation := spec.Paths.Value(url).Delete
+			if operation.RequestBody != nil {
+				t.Errorf("expected the DELETE input not to be documented as a request body")
+			}
+			if name := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "name"); name == nil || !name.Required {
+				t.Errorf("expected the required name query parameter, got %+v", name)
+			}
+		},
+	)
+
+	t.Run(
+		"test responses without output documented without content",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+			swaglay_fiber.Fiber = fiberApp
+			swaglay_fiber.FiberApp = fiberApp
+
+			postUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.Post(api, postUrl, fn, getName())
+			putUrl := addLeadingSlash(getApiUrl())
+			swaglay_fiber.Put(api, putUrl, fn, getName())
+
+			for method, url := range map[string]string{fiber.MethodPost: postUrl, fiber.MethodPut: putUrl} {
+				status := swaglay.SuccessStatus(method, false)
+				if content := sendRequestExpectedStatus(fiberApp, method, url, status); content != "" {
+					t.Errorf("expected %s %s to respond without content, got %s", method, url, content)
+				}
+			}
+
+			spec, err := swaglay.Api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, response := range []*openapi3.ResponseRef{
+				spec.Paths.Value(postUrl).Post.Responses.Value("201"), spec.Paths.Value(putUrl).Put.Responses.Value("200"),
+			} {
+				if response == nil || response.Value.Content != nil {
+					t.Errorf("expected the success response without content, got %+v", response)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"test group with path parameters",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			groupApi := swaglay.NewApi("group-parameters")
+			fnOrg := swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*DataOut, error) {
+				return &DataOut{Name: ctx.Params("orgId")}, nil
+			})
+			swaglay_fiber.NewRegistrar(fiberApp, groupApi).Group("/a/{orgId}").Get(api, "/users", fnOrg, "group users")
+			swaglay_fiber.NewRegistrar(fiberApp.Group("/b/:orgId"), groupApi).Get(api, "/users", fnOrg, "fiber group users")
+
+			spec, err := groupApi.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			for _, url := range []string{"/a/{orgId}/users", "/b/{orgId}/users"} {
+				path := spec.Paths.Value(url)
+				if path == nil || path.Get == nil {
+					t.Fatalf("expected the GET route of %s, got %v", url, spec.Paths.InMatchingOrder())
+				}
+				if orgId := path.Get.Parameters.GetByInAndName(openapi3.ParameterInPath, "orgId"); orgId == nil || !orgId.Required {
+					t.Errorf("expected the orgId path parameter of %s, got %+v", url, orgId)
+				}
+
+				content := sendRequest(fiberApp, fiber.MethodGet, strings.Replace(url, "{orgId}", "42", 1))
+				if content != `{"name":"42"}` {
+					t.Errorf("expected the orgId from the path of %s, got %s", url, content)
+				}
+			}
+		},
+	)
+	t.Run(
+		"test route api responds",
+		func(t *testing.T) {
+			swaglay.SetupApi(api)
+			fiberApp := getFiberApp()
+
+			routeApi := swaglay.NewApi("route-api", rest.WithProblemDetails())
+			routeApi.RegisterError(ErrOrderNotFound, rest.ErrorResponse{Status: fiber.StatusNotFound, Description: "Order not found"})
+
+			registrar := swaglay_fiber.NewRegistrar(fiberApp, swaglay.NewApi("registrar-api"))
+			url := addLeadingSlash(getApiUrl())
+			registrar.Get(api, url, swaglay_fiber.HandleO(func(ctx fiber.Ctx) (*Order, error) {
+				return nil, ErrOrderNotFound
+			}), getName(), swaglay_fiber.Opts{Api: routeApi})
+
+			request, err := http.NewRequest(fiber.MethodGet, url, nil)
+			if err != nil {
+				t.Fatalf("error creating request: %s", err)
+			}
+			response, err := fiberApp.Test(request)
+			if err != nil {
+				t.Fatalf("failed to make request: %s", err)
+			}
+			if response.StatusCode != fiber.StatusNotFound {
+				t.Errorf("expected the status of the error registered in the API of the route, got %d", response.StatusCode)
+			}
+			if contentType := response.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, rest.ProblemJSONContentType) {
+				t.Errorf("expected problem details of the API of the route, got %s", contentType)
+			}
+		},
+	)
 }
@@ -4,8 +4,9 @@ import (
 	"context"
 	"fiber/pkg/constants"
 	"fiber/pkg/controllers"
-	"github.com/KoNekoD/swaglay/pkg"
+	"github.com/KoNekoD/swaglay/pkg/adapters/swaglay_fiber"
 	"github.com/charmbracelet/log"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
@@ -16,8 +17,20 @@ import (
 	"time"
 )
 
+// structValidator validates the inputs by the binding tag, which the API documents, see rest.WithValidateTagName.
+type structValidator struct {
+	validate *validator.Validate
+}
+
+func (v *structValidator) Validate(out any) error {
+	return v.validate.Struct(out)
+}
+
 func main() {
-	r := fiber.New()
+	validate := validator.New()
+	validate.SetTagName("binding")
+
+	r := fiber.New(fiber.Config{StructValidator: &structValidator{validate: validate}})
 
 	controllers.InitAllControllers(r)
 
@@ -27,6 +40,7 @@ func main() {
 
 	log.Info("Server started at http://localhost:8080")
 	log.Info("OpenAPI json at http://localhost:8080/api/docs/openapi.json")
+	log.Info("OpenAPI yaml at http://localhost:8080/api/docs/openapi.yaml")
 
 	go func() {
 		err := srv.ListenAndServe(constants.ServerAddr)
@@ -54,13 +68,6 @@ func main() {
 }
 
 func includeApiDocs(r *fiber.App) {
-	apiSpec, _ := swaglay.Api.Spec()
-
-	swaggerBytes, err := apiSpec.MarshalJSON()
-	if err != nil {
-		panic(err)
-	}
-
 	// Examples:
 	// * Stoplight Elements https://stoplight.io/open-source/elements
 	// * Redocly https://redocly.com/
@@ -80,11 +87,6 @@ func includeApiDocs(r *fiber.App) {
 	//	panic(err)
 	//}
 
-	getSwagger := func(ctx fiber.Ctx) error {
-		ctx.Set("Content-Type", constants.JsonContentType)
-		return ctx.Send(swaggerBytes)
-	}
-
 	//getDocsHtml := func(ctx fiber.Ctx) error {
 	//	ctx.Set("Content-Type", constants.HtmlContentType)
 	//	return ctx.Send(htmlBytes)
@@ -100,9 +102,12 @@ func includeApiDocs(r *fiber.App) {
 	//	return ctx.Send(cssBytes)
 	//}
 
-	r.Group("/api/docs").
-		//Get("", getDocsHtml).
-		//Get("/web-components.min.js", getDocsJs).
-		//Get("/styles.min.css", getDocsCss).
-		Get("/openapi.json", getSwagger)
+	docs := r.Group("/api/docs")
+	//docs.Get("", getDocsHtml)
+	//docs.Get("/web-components.min.js", getDocsJs)
+	//docs.Get("/styles.min.css", getDocsCss)
+
+	if err := swaglay_fiber.ServeSpec(docs); err != nil {
+		panic(err)
+	}
 }
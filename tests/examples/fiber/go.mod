@@ -5,6 +5,7 @@ go 1.24
 require (
 	github.com/KoNekoD/swaglay v0.0.2
 	github.com/charmbracelet/log v0.4.2
+	github.com/go-playground/validator/v10 v10.26.0
 	github.com/gofiber/fiber/v3 v3.0.0-beta.4
 	github.com/pkg/errors v0.9.1
 	github.com/valyala/fasthttp v1.62.0
@@ -27,7 +28,6 @@ require (
 	github.com/go-openapi/swag v0.23.0 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
-	github.com/go-playground/validator/v10 v10.26.0 // indirect
 	github.com/gofiber/schema v1.2.0 // indirect
 	github.com/gofiber/utils/v2 v2.0.0-beta.8 // indirect
 	github.com/google/uuid v1.6.0 // indirect
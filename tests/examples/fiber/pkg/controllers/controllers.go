@@ -5,18 +5,18 @@ import (
 	"fiber/pkg/services"
 	swaglay "github.com/KoNekoD/swaglay/pkg"
 	"github.com/KoNekoD/swaglay/pkg/adapters/swaglay_fiber"
+	"github.com/KoNekoD/swaglay/pkg/rest"
 	"github.com/gofiber/fiber/v3"
 )
 
 func InitAllControllers(r *fiber.App) {
-	swaglay.SetupApi("Test Application")
-	swaglay_fiber.Fiber = r
-	swaglay_fiber.FiberApp = r
+	swaglay.SetupApi("Test Application", rest.WithValidateTagName("binding"))
+	registrar := swaglay_fiber.NewRegistrar(r, swaglay.Api)
 
 	var userRepository *repositories.UserRepository
 	var userManager *services.UserManager
 
 	// ...
 
-	NewUserController(userRepository, userManager).Init(r)
+	NewUserController(userRepository, userManager).Init(registrar)
 }
@@ -6,7 +6,6 @@ import (
 	"fiber/pkg/services"
 	. "github.com/KoNekoD/swaglay/pkg/adapters/swaglay_fiber"
 	"github.com/gofiber/fiber/v3"
-	"strconv"
 )
 
 type UserController struct {
@@ -24,13 +23,13 @@ func NewUserController(
 	}
 }
 
-func (c *UserController) Init(r *fiber.App) {
+func (c *UserController) Init(r *Registrar) {
 	const api = "users"
-	Fiber = r.Group("/api/users") // Add too `.Use(c.authMiddleware.Exec)`
-	GetO(api, "/me", c.Me, "Me")
-	PostI(api, "/change-email", c.ChangeEmail, "Change email")
-	Delete(api, "/delete-account", c.DeleteAccount, "Delete account")
-	Delete(api, "/{id}", c.DeleteAccount, "Delete account")
+	users := r.Group("/api/users") // Add too `, c.authMiddleware.Exec`
+	users.Get(api, "/me", HandleO(c.Me), "Me")
+	users.Post(api, "/change-email", HandleI(c.ChangeEmail), "Change email")
+	users.Delete(api, "/delete-account", Handle(c.DeleteAccount), "Delete account")
+	users.Delete(api, "/{id}", HandleI(c.DeleteUser), "Delete user")
 }
 
 func (c *UserController) Me(ctx fiber.Ctx) (*dtos.UserDto, error) {
@@ -46,8 +45,11 @@ func (c *UserController) ChangeEmail(i *dtos.ChangeEmail, ctx fiber.Ctx) error {
 }
 
 func (c *UserController) DeleteAccount(ctx fiber.Ctx) error {
-	userIdStr := ctx.Params("id")
-	userId, _ := strconv.Atoi(userIdStr)
+	userId := 123
 
 	return c.userManager.DeleteAccount(userId)
 }
+
+func (c *UserController) DeleteUser(i *dtos.DeleteUser, ctx fiber.Ctx) error {
+	return c.userManager.DeleteAccount(i.ID)
+}
@@ -5,3 +5,8 @@ type ChangeEmail struct {
 }
 
 type UserDto struct{}
+
+type DeleteUser struct {
+	// ID of the user to delete.
+	ID int `path:"id" binding:"min=1"`
+}
@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type concurrentItem struct {
+	Name     string            `json:"name"`
+	Children []*concurrentItem `json:"children"`
+}
+
+type concurrentError struct {
+	Message string `json:"message"`
+}
+
+// commentedLink has a field of a type of the standard library.
+type commentedLink struct {
+	URL url.URL `json:"url"`
+}
+
+// Run with -race to detect unguarded access.
+func TestAPI(t *testing.T) {
+	t.Run(
+		"test concurrent registration and spec reads",
+		func(t *testing.T) {
+			api := rest.NewAPI("concurrent", rest.WithSecurityScheme("bearer", rest.NewJWTSecurityScheme()))
+
+			const writers, readers, routes, reads = 8, 4, 10, 10
+
+			var wg sync.WaitGroup
+
+			for w := range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range routes {
+						pattern := fmt.Sprintf("/writers%d/items%d/{id}", w, i)
+						// Routes with path parameters are merged at once, since they're invalid without them.
+						api.Merge(
+							*rest.NewRoute(http.MethodGet, pattern).
+								HasPathParameter("id", rest.PathParam{}).
+								HasTags([]string{"items"}).
+								HasSecurity("bearer").
+								HasResponseModel(http.StatusOK, rest.ModelOf[concurrentItem]()),
+						)
+						api.Merge(
+							rest.Route{
+								Method:  http.MethodDelete,
+								Pattern: rest.Pattern(pattern),
+								Params: rest.Params{
+									Path: map[string]rest.PathParam{"id": {}},
+								},
+								Models: rest.Models{
+									Responses: map[int]rest.Model{
+										http.StatusNotFound: rest.ModelOf[concurrentError](),
+									},
+								},
+							},
+						)
+						// All writers share a route, so the same route is modified concurrently,
+						// and Spec sees it as it's configured.
+						api.Post("/items").
+							HasRequestModel(rest.ModelOf[concurrentItem]()).
+							HasResponseModel(http.StatusCreated, rest.ModelOf[concurrentItem]())
+						api.MustRegisterModel(rest.ModelOf[[]concurrentItem]())
+					}
+				}()
+			}
+
+			for range readers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range reads {
+						if _, err := api.Spec(); err != nil {
+							t.Errorf("failed to create spec: %s", err)
+							return
+						}
+						if _, err := api.SpecJSON(); err != nil {
+							t.Errorf("failed to render spec: %s", err)
+							return
+						}
+						if _, err := api.SpecYAML(); err != nil {
+							t.Errorf("failed to render spec: %s", err)
+							return
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			spec, err := api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+			if excepted := writers*routes + 1; spec.Paths.Len() != excepted {
+				t.Errorf("expected %d paths, got %d", excepted, spec.Paths.Len())
+			}
+			for w := range writers {
+				for i := range routes {
+					path := spec.Paths.Value(fmt.Sprintf("/writers%d/items%d/{id}", w, i))
+					if path == nil || path.Get == nil || path.Delete == nil {
+						t.Fatalf("expected GET and DELETE operations for writer %d, route %d", w, i)
+					}
+				}
+			}
+			if _, ok := spec.Components.Schemas["concurrentItem"]; !ok {
+				t.Errorf("expected concurrentItem in components, got %v", spec.Components.Schemas)
+			}
+		},
+	)
+	t.Run(
+		"test add route and merge",
+		func(t *testing.T) {
+			api := rest.NewAPI("add-route")
+			first := func() rest.Route {
+				return *rest.NewRoute(http.MethodGet, "/items").
+					HasTags([]string{"first"}).
+					HasOperationID("first").
+					HasDescription("First.").
+					HasQueryParameter("q", rest.QueryParam{Description: "First."}).
+					HasResponseModel(http.StatusOK, rest.ModelOf[concurrentItem]())
+			}
+			second := *rest.NewRoute(http.MethodGet, "/items").
+				HasTags([]string{"second"}).
+				HasOperationID("second").
+				HasQueryParameter("q", rest.QueryParam{Description: "Second."}).
+				HasResponseModel(http.StatusOK, rest.ModelOf[concurrentError]())
+
+			api.AddRoute(first())
+			api.AddRoute(second)
+			api.Merge(first())
+
+			route := api.Get("/items")
+			if route.OperationID != "second" || route.Description != "First." {
+				t.Errorf("expected the operation ID of the last added route and the description of the first one, got %q and %q",
+					route.OperationID, route.Description)
+			}
+			if len(route.Tags) != 2 || route.Tags[0] != "first" || route.Tags[1] != "second" {
+				t.Errorf("expected the tags of both routes, got %v", route.Tags)
+			}
+			if description := route.Params.Query["q"].Description; description != "Second." {
+				t.Errorf("expected the query parameter of the last added route, got %q", description)
+			}
+			if model := route.Models.Responses[http.StatusOK]; model.Type != rest.ModelOf[concurrentError]().Type {
+				t.Errorf("expected the response of the last added route, got %v", model.Type)
+			}
+		},
+	)
+	t.Run(
+		"test comments of the standard library",
+		func(t *testing.T) {
+			api := rest.NewAPI("comments")
+			api.Get("/links").HasResponseModel(http.StatusOK, rest.ModelOf[commentedLink]())
+
+			spec, err := api.Spec()
+			if err != nil {
+				t.Fatalf("failed to create spec: %s", err)
+			}
+
+			link := spec.Components.Schemas["commentedLink"]
+			if link == nil || link.Value.Description != "commentedLink has a field of a type of the standard library." {
+				t.Fatalf("expected the doc comment of commentedLink, got %+v", link)
+			}
+			ref := link.Value.Properties["url"].Ref
+			urlSchema := spec.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+			if urlSchema == nil || urlSchema.Value.Description != "" {
+				t.Errorf("expected %s without the doc comment of url.URL, got %+v", ref, urlSchema)
+			}
+		},
+	)
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/KoNekoD/swaglay/pkg/rest"
+	"net/http"
+	"testing"
+)
+
+type benchmarkUser struct {
+	ID    string `json:"id"`
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"email"`
+}
+
+type benchmarkCreateUser struct {
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"email"`
+}
+
+type benchmarkError struct {
+	Message string `json:"message"`
+}
+
+// newBenchmarkAPI creates an API with resources CRUD resources, of 5 operations each.
+func newBenchmarkAPI(resources int) *rest.API {
+	api := rest.NewAPI("benchmark")
+	for i := range resources {
+		collection := fmt.Sprintf("/resources%d", i)
+		item := collection + "/{id}"
+
+		api.Get(collection).
+			HasQueryParameter("limit", rest.QueryParam{Type: rest.PrimitiveTypeInteger}).
+			HasResponseModel(http.StatusOK, rest.ModelOf[[]benchmarkUser]())
+		api.Post(collection).
+			HasRequestModel(rest.ModelOf[benchmarkCreateUser]()).
+			HasResponseModel(http.StatusCreated, rest.ModelOf[benchmarkUser]()).
+			HasResponseModel(http.StatusBadRequest, rest.ModelOf[benchmarkError]())
+		api.Get(item).
+			HasPathParameter("id", rest.PathParam{}).
+			HasResponseModel(http.StatusOK, rest.ModelOf[benchmarkUser]()).
+			HasResponseModel(http.StatusNotFound, rest.ModelOf[benchmarkError]())
+		api.Put(item).
+			HasPathParameter("id", rest.PathParam{}).
+			HasRequestModel(rest.ModelOf[benchmarkCreateUser]()).
+			HasResponseModel(http.StatusOK, rest.ModelOf[benchmarkUser]())
+		api.Delete(item).
+			HasPathParameter("id", rest.PathParam{}).
+			HasResponseModel(http.StatusNoContent, rest.ModelOf[benchmarkError]())
+	}
+	return api
+}
+
+func BenchmarkSpec(b *testing.B) {
+	for _, resources := range []int{200, 1000} {
+		api := newBenchmarkAPI(resources)
+
+		b.Run(
+			fmt.Sprintf("build %d operations", resources*5),
+			func(b *testing.B) {
+				for b.Loop() {
+					api.InvalidateSpec()
+					if _, err := api.Spec(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			},
+		)
+
+		b.Run(
+			fmt.Sprintf("cached %d operations", resources*5),
+			func(b *testing.B) {
+				if _, err := api.SpecJSON(); err != nil {
+					b.Fatal(err)
+				}
+				for b.Loop() {
+					if _, err := api.SpecJSON(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			},
+		)
+	}
+}